@@ -0,0 +1,250 @@
+package mongo
+
+import (
+	"reflect"
+
+	"github.com/globalsign/mgo"
+)
+
+// bulkKind identifies the kind of a BulkOperation.
+type bulkKind int
+
+const (
+	bulkInsert bulkKind = iota
+	bulkUpdate
+	bulkUpdateAll
+	bulkUpsert
+	bulkRemove
+	bulkRemoveAll
+)
+
+// BulkOperation it's a single operation to be run on BulkWrite. Use
+// the BulkInsert, BulkUpdate, BulkUpdateAll, BulkUpsert, BulkRemove
+// and BulkRemoveAll functions to create them.
+type BulkOperation struct {
+	kind     bulkKind
+	selector M
+	document Documenter
+	update   interface{}
+}
+
+// BulkInsert creates an operation inserting document d, generating
+// its ID and created_on like Insert does.
+func BulkInsert(d Documenter) (op BulkOperation) {
+	op = BulkOperation{
+		kind:     bulkInsert,
+		document: d,
+	}
+	return
+}
+
+// BulkUpdate creates an operation updating the first document matching
+// selector. The update can be an *Ops, a M with update operators, or a
+// M or Documenter replacing the whole document.
+func BulkUpdate(selector M, update interface{}) (op BulkOperation) {
+	op = BulkOperation{
+		kind:     bulkUpdate,
+		selector: selector,
+		update:   update,
+	}
+	return
+}
+
+// BulkUpdateAll creates an operation updating all documents matching
+// selector, with the same updates accepted by BulkUpdate.
+func BulkUpdateAll(selector M, update interface{}) (op BulkOperation) {
+	op = BulkOperation{
+		kind:     bulkUpdateAll,
+		selector: selector,
+		update:   update,
+	}
+	return
+}
+
+// BulkUpsert creates an operation inserting or updating document d,
+// matched by selector, or by its ID if selector is empty. It sets
+// created_on and updated_on like Upsert does.
+func BulkUpsert(selector M, d Documenter) (op BulkOperation) {
+	op = BulkOperation{
+		kind:     bulkUpsert,
+		selector: selector,
+		document: d,
+	}
+	return
+}
+
+// BulkRemove creates an operation removing the first document matching
+// selector.
+func BulkRemove(selector M) (op BulkOperation) {
+	op = BulkOperation{
+		kind:     bulkRemove,
+		selector: selector,
+	}
+	return
+}
+
+// BulkRemoveAll creates an operation removing all documents matching
+// selector.
+func BulkRemoveAll(selector M) (op BulkOperation) {
+	op = BulkOperation{
+		kind:     bulkRemoveAll,
+		selector: selector,
+	}
+	return
+}
+
+// BulkResult holds the results of a BulkWrite. Matched and Modified
+// are only available when all operations succeed. Errors holds the
+// errors of each operation failed, with its position on the
+// operations received, or -1 when the server doesn't report it. In
+// this case, the inserts succeeded are unknown, and Inserted is 0.
+type BulkResult struct {
+	Inserted int
+	Matched  int
+	Modified int
+	Errors   []mgo.BulkErrorCase
+}
+
+// InsertMany puts new documents on collection connected to Handle,
+// using a single bulk operation. It generates IDs and created_on for
+// each document like Insert does.
+func (h *Handle) InsertMany(docs []Documenter) (result *BulkResult, err error) {
+	ops := make([]BulkOperation, len(docs))
+	for i := range docs {
+		ops[i] = BulkInsert(docs[i])
+	}
+
+	result, err = h.BulkWrite(true, ops...)
+	return
+}
+
+// BulkWrite runs all operations received on collection connected to
+// Handle, using a single bulk operation. When ordered, the operations
+// run in sequence, stopping at first error. Otherwise they may run in
+// any order, and the failure of one doesn't stop the others.
+func (h *Handle) BulkWrite(ordered bool, ops ...BulkOperation) (result *BulkResult, err error) {
+	defer h.ifSafelyClose()
+	defer wrapErr(&err)
+
+	result = &BulkResult{}
+
+	if err = h.InternalErr; err == nil && len(ops) > 0 {
+		bulk := h.collection.Bulk()
+		if !ordered {
+			bulk.Unordered()
+		}
+
+		for i := 0; i < len(ops) && err == nil; i++ {
+			err = queue(bulk, ops[i])
+		}
+
+		if err == nil {
+			var res *mgo.BulkResult
+			if res, err = bulk.Run(); err == nil {
+				result.Matched = res.Matched
+				result.Modified = res.Modified
+				result.Inserted = inserted(ops, nil, ordered)
+			} else if berr, ok := err.(*mgo.BulkError); ok {
+				result.Errors = berr.Cases()
+				result.Inserted = inserted(ops, result.Errors, ordered)
+			}
+		}
+	}
+
+	return
+}
+
+// queue adds the operation op to the bulk received, preparing its
+// documents like the single operations of Handle do.
+func queue(bulk *mgo.Bulk, op BulkOperation) (err error) {
+	switch op.kind {
+	case bulkInsert:
+		var mapped M
+		if mapped, err = insertDocument(op.document); err == nil {
+			bulk.Insert(mapped)
+		}
+	case bulkUpdate, bulkUpdateAll:
+		var update M
+		if update, err = updateDocument(op.update); err == nil {
+			if op.kind == bulkUpdate {
+				bulk.Update(op.selector, update)
+			} else {
+				bulk.UpdateAll(op.selector, update)
+			}
+		}
+	case bulkUpsert:
+		if op.document == nil || reflect.ValueOf(op.document).IsNil() {
+			err = DocNotDefined
+		} else {
+			selector := op.selector
+			if len(selector) == 0 {
+				if op.document.ID() == "" {
+					op.document.GenerateID()
+				}
+
+				selector = M{
+					"_id": op.document.ID(),
+				}
+			}
+
+			var update M
+			if update, err = upsertDocument(selector, op.document); err == nil {
+				bulk.Upsert(selector, update)
+			}
+		}
+	case bulkRemove:
+		bulk.Remove(op.selector)
+	case bulkRemoveAll:
+		bulk.RemoveAll(op.selector)
+	}
+
+	return
+}
+
+// insertDocument translates the document d to be inserted, generating
+// its ID if not defined and its created_on.
+func insertDocument(d Documenter) (mapped M, err error) {
+	if d == nil || reflect.ValueOf(d).IsNil() {
+		err = DocNotDefined
+	} else {
+		bind(d)
+
+		if d.ID() == "" {
+			d.GenerateID()
+		}
+
+		d.CalculateCreatedOn()
+
+		if mapped, err = d.Map(); err == nil {
+			mapped["_id"] = d.ID()
+			mapped["created_on"] = d.CreatedOn()
+		}
+	}
+
+	return
+}
+
+// inserted counts the insert operations that succeeded, given the
+// errors found. On ordered operations, nothing runs after the first
+// error. When an error has no index, the operations succeeded are
+// unknown, and none is counted.
+func inserted(ops []BulkOperation, errs []mgo.BulkErrorCase, ordered bool) (n int) {
+	failed := make(map[int]bool)
+	stop := len(ops)
+	for _, e := range errs {
+		failed[e.Index] = true
+		if e.Index < 0 {
+			stop = 0
+		} else if ordered && e.Index < stop {
+			stop = e.Index
+		}
+	}
+
+	for i := 0; i < stop; i++ {
+		if ops[i].kind == bulkInsert && !failed[i] {
+			n++
+		}
+	}
+
+	return
+}
@@ -0,0 +1,175 @@
+package mongo
+
+import (
+	"reflect"
+
+	"github.com/ddspog/mongo/internal/bsonutils"
+)
+
+// maxSnapshots it's the number of snapshots kept by a Handle. When
+// it's reached, the oldest snapshots are discarded.
+const maxSnapshots = 128
+
+// snapshotOptions are the options used to map documents compared by
+// UpdateChanges, keeping empty fields so changes to zero values are
+// set instead of unset.
+var snapshotOptions = bsonutils.Options{
+	OmitEmptyIDs: true,
+	Registry:     registry,
+}
+
+// snapshotStore it's a store of documents mapped, by their IDs,
+// keeping up to maxSnapshots of them. Its zero value is ready to use.
+type snapshotStore struct {
+	mapped map[ObjectId]M
+	order  []ObjectId
+}
+
+// UpdateChanges updates a document on collection connected to Handle,
+// matching id received, setting only the fields changed on doc. The
+// changes are calculated comparing doc with the original received,
+// or with the snapshot taken when the document was found with Find.
+// Only the snapshots of the last documents found are kept.
+//
+// Changed fields, including the ones on embedded documents, are set
+// with $set, and removed fields are removed with $unset, leaving
+// untouched any other field on database. It also updates updated_on.
+func (h *Handle) UpdateChanges(id ObjectId, original ...Documenter) (err error) {
+	defer h.ifSafelyClose()
+	defer wrapErr(&err)
+
+	if err = h.InternalErr; err == nil {
+		if id == "" {
+			err = ErrIDNotDefined
+		} else {
+			var old M
+			if old, err = h.original(id, original...); err == nil {
+				h.Document().CalculateUpdatedOn()
+
+				var mapped M
+				if mapped, err = fullMap(h.Document()); err == nil {
+					set, unset := M{}, M{}
+					diff("", old, mapped, set, unset)
+					set["updated_on"] = h.Document().UpdatedOn()
+
+					update := M{
+						"$set": set,
+					}
+					if len(unset) > 0 {
+						update["$unset"] = unset
+					}
+
+					idSelector := M{
+						"_id": id,
+					}
+
+					if err = h.collection.Update(idSelector, update); err == nil {
+						h.snapshots.put(id, mapped)
+					}
+				}
+			}
+		}
+	}
+
+	return
+}
+
+// original returns the mapped version of the original document
+// received, or the snapshot stored for id when no original is given.
+func (h *Handle) original(id ObjectId, original ...Documenter) (m M, err error) {
+	if len(original) == 1 && original[0] != nil {
+		bind(original[0])
+		m, err = fullMap(original[0])
+	} else if snapshot, ok := h.snapshots.get(id); ok {
+		m = snapshot
+	} else {
+		err = ErrNoSnapshot
+	}
+
+	return
+}
+
+// fullMap translates the document d to a M object, keeping its empty
+// fields, to be compared by UpdateChanges.
+func fullMap(d Documenter) (out M, err error) {
+	out, err = bsonutils.ToMapWith(snapshotOptions, d)
+	return
+}
+
+// get returns the snapshot stored for id, if any.
+func (s *snapshotStore) get(id ObjectId) (m M, ok bool) {
+	m, ok = s.mapped[id]
+	return
+}
+
+// put stores m as the snapshot for id, discarding the oldest snapshot
+// when there are more than maxSnapshots.
+func (s *snapshotStore) put(id ObjectId, m M) {
+	if s.mapped == nil {
+		s.mapped = make(map[ObjectId]M)
+	}
+
+	if _, found := s.mapped[id]; !found {
+		s.order = append(s.order, id)
+		if len(s.order) > maxSnapshots {
+			delete(s.mapped, s.order[0])
+			s.order = s.order[1:]
+		}
+	}
+
+	s.mapped[id] = m
+}
+
+// clone returns a copy of the store, that can be changed without
+// changing the original.
+func (s *snapshotStore) clone() (c snapshotStore) {
+	c.mapped = make(map[ObjectId]M, len(s.mapped))
+	for id, m := range s.mapped {
+		c.mapped[id] = m
+	}
+	c.order = append([]ObjectId(nil), s.order...)
+	return
+}
+
+// diff compares old and cur documents, storing on set the paths with
+// values changed or added, and on unset the paths removed. Embedded
+// documents are compared field by field, using dotted paths. The
+// fields _id, created_on and updated_on are ignored.
+func diff(prefix string, old, cur M, set, unset M) {
+	for k, v := range cur {
+		if prefix == "" && isManagedField(k) {
+			continue
+		}
+
+		path := prefix + k
+		if ov, found := old[k]; !found {
+			set[path] = v
+		} else {
+			om, oldIsMap := ov.(M)
+			nm, newIsMap := v.(M)
+
+			if oldIsMap && newIsMap {
+				diff(path+".", om, nm, set, unset)
+			} else if !reflect.DeepEqual(ov, v) {
+				set[path] = v
+			}
+		}
+	}
+
+	for k := range old {
+		if prefix == "" && isManagedField(k) {
+			continue
+		}
+
+		if _, found := cur[k]; !found {
+			unset[prefix+k] = ""
+		}
+	}
+}
+
+// isManagedField checks if key it's one of the fields managed by
+// Handle: _id, created_on and updated_on.
+func isManagedField(key string) (managed bool) {
+	managed = key == "_id" || key == "created_on" || key == "updated_on"
+	return
+}
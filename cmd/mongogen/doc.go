@@ -0,0 +1,61 @@
+/*
+Command mongogen generates the boilerplate of documents stored with
+mongo package: the Documenter methods, a typed Handle, constants with
+the keys of fields, and the indexes of collection.
+
+It reads the structs of a package marked with the directive
+//mongo:document, followed by the name of collection storing them.
+Compound indexes are declared with the directive //mongo:index,
+followed by keys separated by comma, and the options unique and
+sparse. Indexes of a single field are declared on the mongo tag of
+field, with the options index, unique, sparse and desc:
+
+	//go:generate mongogen
+
+	// Product it's a product sold.
+	//mongo:document products
+	//mongo:index name,-price unique
+	type Product struct {
+		IDV        mongo.ObjectId `bson:"_id"`
+		CreatedOnV int64          `bson:"created_on"`
+		UpdatedOnV int64          `bson:"updated_on"`
+		NameV      string         `bson:"name" mongo:"unique"`
+		PriceV     float32        `bson:"price" mongo:"desc"`
+	}
+
+Running go generate creates, for each file declaring documents, a file
+with suffix _mongo.go containing:
+
+	const (
+		ProductCollection = "products"
+		ProductID         = "_id"
+		ProductName       = "name"
+		// ...
+	)
+
+	var ProductIndexes = []mgo.Index{
+		// ...
+	}
+
+	type ProductHandle = mongo.TypedHandle[*Product]
+
+	func NewProductHandle() (h *ProductHandle)
+
+	func (p *Product) New() (doc mongo.Documenter)
+	// And the other Documenter methods.
+
+Documenter methods already declared on package, like Validate, aren't
+generated, so they can be customized. The structs must have fields
+stored with keys _id, created_on and updated_on, or embed
+mongo.Document inline, when only New is generated. Other fields
+inlined have no key constants generated. Names generated
+colliding with each other, or with declarations of package, are
+reported as errors, like two fields NameV and Name on Product.
+
+Usage:
+
+	mongogen [dir]
+
+Where dir it's the directory of package, by default the current one.
+*/
+package main
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// tmpl it's the template of files generated, receiving the package
+// name and the models of a file.
+var tmpl = template.Must(template.New("mongogen").Funcs(template.FuncMap{
+	"quote": strconv.Quote,
+	"keys":  quoteKeys,
+}).Parse(`// Code generated by mongogen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"github.com/ddspog/mongo"
+	"github.com/globalsign/mgo"
+)
+{{range .Models}}{{$r := .Receiver}}{{$t := .Name}}
+const (
+	// {{$t}}Collection it's the name of collection storing {{$t}}
+	// documents.
+	{{$t}}Collection = {{quote .Collection}}
+{{- range .Fields}}
+	// {{.Const}} it's the key of field {{.Name}} on {{$t}} documents.
+	{{.Const}} = {{quote .Key}}
+{{- end}}
+)
+
+// {{$t}}Indexes are the indexes of collection storing {{$t}}
+// documents, loaded by New{{$t}}Handle.
+var {{$t}}Indexes = []mgo.Index{
+{{- range .Indexes}}
+	{Key: []string{ {{- keys .Keys -}} }{{if .Unique}}, Unique: true{{end}}{{if .Sparse}}, Sparse: true{{end}}},
+{{- end}}
+}
+
+// {{$t}}Handle it's a Handle storing {{$t}} documents.
+type {{$t}}Handle = mongo.TypedHandle[*{{$t}}]
+
+// New{{$t}}Handle creates a new {{$t}}Handle, linked to collection
+// storing {{$t}} documents, with its indexes loaded.
+func New{{$t}}Handle() (h *{{$t}}Handle) {
+	h = mongo.NewTypedHandle({{$t}}Collection, &{{$t}}{}, {{$t}}Indexes...)
+	return
+}
+{{if .Generates "New"}}
+// New creates a new instance of {{$t}}, used on another functions for
+// clone purposes.
+func ({{$r}} *{{$t}}) New() (doc mongo.Documenter) {
+{{- if .Embedded}}
+	created := &{{$t}}{}
+	created.Bind(created)
+	doc = created
+{{- else}}
+	doc = &{{$t}}{}
+{{- end}}
+	return
+}
+{{end}}{{if not .Embedded}}{{if .Generates "Validate"}}
+// Validate checks for problems on {{$t}}. Declare it on {{$t}} to
+// check its fields.
+func ({{$r}} *{{$t}}) Validate() (err error) {
+	return
+}
+{{end}}{{if .Generates "Map"}}
+// Map translates a {{$t}} to a M object, more easily read by mgo
+// methods.
+func ({{$r}} *{{$t}}) Map() (out mongo.M, err error) {
+	out, err = mongo.MapDocumenter({{$r}})
+	return
+}
+{{end}}{{if .Generates "Init"}}
+// Init translates a M received, to the {{$t}} structure. It fills the
+// structure fields with the values of each key in the M received.
+func ({{$r}} *{{$t}}) Init(in mongo.M) (err error) {
+	var doc mongo.Documenter = {{$r}}
+	err = mongo.InitDocumenter(in, &doc)
+	return
+}
+{{end}}{{if .Generates "ID"}}
+// ID returns the _id attribute of a {{$t}}.
+func ({{$r}} *{{$t}}) ID() (id mongo.ObjectId) {
+	id = {{$r}}.{{.IDField}}
+	return
+}
+{{end}}{{if .Generates "CreatedOn"}}
+// CreatedOn returns the created_on attribute of a {{$t}}.
+func ({{$r}} *{{$t}}) CreatedOn() (t int64) {
+	t = {{$r}}.{{.CreatedOn}}
+	return
+}
+{{end}}{{if .Generates "UpdatedOn"}}
+// UpdatedOn returns the updated_on attribute of a {{$t}}.
+func ({{$r}} *{{$t}}) UpdatedOn() (t int64) {
+	t = {{$r}}.{{.UpdatedOn}}
+	return
+}
+{{end}}{{if .Generates "GenerateID"}}
+// GenerateID creates a new id for a {{$t}}.
+func ({{$r}} *{{$t}}) GenerateID() {
+	{{$r}}.{{.IDField}} = mongo.NewID()
+}
+{{end}}{{if .Generates "CalculateCreatedOn"}}
+// CalculateCreatedOn update the created_on attribute with a value
+// corresponding to actual time.
+func ({{$r}} *{{$t}}) CalculateCreatedOn() {
+	{{$r}}.{{.CreatedOn}} = mongo.NowInMilli()
+}
+{{end}}{{if .Generates "CalculateUpdatedOn"}}
+// CalculateUpdatedOn update the updated_on attribute with a value
+// corresponding to actual time.
+func ({{$r}} *{{$t}}) CalculateUpdatedOn() {
+	{{$r}}.{{.UpdatedOn}} = mongo.NowInMilli()
+}
+{{end}}{{end}}{{end}}`))
+
+// generate returns the files generated for the models of package p,
+// by the name of file where they're declared.
+func generate(p *pkg) (files map[string][]byte, err error) {
+	names := make([]string, 0, len(p.Files))
+	for name := range p.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	files = make(map[string][]byte)
+	for i := 0; i < len(names) && err == nil; i++ {
+		var src []byte
+		if src, err = generateFile(p.Name, p.Files[names[i]]); err == nil {
+			files[outputName(names[i])] = src
+		} else {
+			err = fmt.Errorf("%s: %v", names[i], err)
+		}
+	}
+
+	if err != nil {
+		files = nil
+	}
+
+	return
+}
+
+// generateFile returns the source generated for the models of package
+// named pkgName, formatted.
+func generateFile(pkgName string, models []*model) (src []byte, err error) {
+	var buf bytes.Buffer
+	if err = tmpl.Execute(&buf, struct {
+		Package string
+		Models  []*model
+	}{pkgName, models}); err == nil {
+		src, err = format.Source(buf.Bytes())
+	}
+	return
+}
+
+// quoteKeys returns the index keys quoted and separated by comma, to be
+// used on a slice literal.
+func quoteKeys(keys []string) (s string) {
+	quoted := make([]string, len(keys))
+	for i := range keys {
+		quoted[i] = strconv.Quote(keys[i])
+	}
+	s = strings.Join(quoted, ", ")
+	return
+}
+
+// outputName returns the name of the file generated for file name.
+func outputName(name string) (out string) {
+	out = strings.TrimSuffix(name, ".go") + generatedSuffix
+	return
+}
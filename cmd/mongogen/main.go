@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("mongogen: ")
+
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: mongogen [dir]\n\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "Generates the code of structs marked with %s on package at dir, or the current directory.\n", documentDirective)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+
+	if err := run(dir); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run generates the code of models found on package at dir, writing a
+// file for each file declaring models.
+func run(dir string) (err error) {
+	var p *pkg
+	if p, err = parseDir(dir); err == nil {
+		var files map[string][]byte
+		if files, err = generate(p); err == nil {
+			for name, src := range files {
+				if err = os.WriteFile(name, src, 0644); err != nil {
+					break
+				}
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,181 @@
+// +build !acceptance
+
+package main
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ddspog/bdd"
+)
+
+const (
+	// models it's a source declaring models to generate code for.
+	models = `package models
+
+import (
+	db "github.com/ddspog/mongo"
+)
+
+// Product it's a document declaring all fields.
+//mongo:document products
+//mongo:index name,-price unique
+type Product struct {
+	IDV        db.ObjectId ` + "`bson:\"_id\"`" + `
+	CreatedOnV int64       ` + "`bson:\"created_on\"`" + `
+	UpdatedOnV int64       ` + "`bson:\"updated_on\"`" + `
+	NameV      string      ` + "`bson:\"name\" mongo:\"unique\"`" + `
+	PriceV     float32     ` + "`bson:\"price,omitempty\" mongo:\"desc,sparse\"`" + `
+	Secret     string      ` + "`bson:\"-\"`" + `
+	Extra      Extra       ` + "`bson:\",inline\"`" + `
+}
+
+// Extra it's a struct inlined on Product.
+type Extra struct {
+	Note string ` + "`bson:\"note\"`" + `
+}
+
+// Validate checks the fields of Product.
+func (p *Product) Validate() (err error) {
+	return
+}
+
+type (
+	// Item it's a document embedding Document.
+	//mongo:document items
+	Item struct {
+		db.Document ` + "`bson:\",inline\"`" + `
+		Title       string ` + "`bson:\"title\" mongo:\"index\"`" + `
+	}
+
+	// Tag it's a type not marked.
+	Tag struct{}
+)
+`
+)
+
+// writeModels writes the source src on a new directory, returning it.
+func writeModels(t *testing.T, src string) (dir string) {
+	dir = t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "models.go"), []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+// typeCheck type checks the package on dir, with the files generated,
+// importing its dependencies from source.
+func typeCheck(dir string) (err error) {
+	fset := token.NewFileSet()
+
+	var pkgs map[string]*ast.Package
+	if pkgs, err = parser.ParseDir(fset, dir, nil, 0); err == nil {
+		for name, p := range pkgs {
+			files := make([]*ast.File, 0, len(p.Files))
+			for _, f := range p.Files {
+				files = append(files, f)
+			}
+
+			conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+			if _, err = conf.Check(name, fset, files, nil); err != nil {
+				break
+			}
+		}
+	}
+
+	return
+}
+
+// Feature Generate code of documents with mongogen
+// - As a developer,
+// - I want to generate Documenter methods, typed handles, field names and indexes,
+// - So that I only need to declare the fields of my documents.
+func Test_Generate_code_of_documents_with_mongogen(t *testing.T) {
+	given, like, s := bdd.Sentences().All()
+
+	given(t, "a package with Product and Item marked as documents", func(when bdd.When) {
+		dir := writeModels(t, models)
+
+		when("run(dir) is called", func(it bdd.It) {
+			err := run(dir)
+			out, errRead := os.ReadFile(filepath.Join(dir, "models_mongo.go"))
+			src := string(out)
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.NoError(err)
+				assert.NoError(errRead)
+			})
+			it("should generate code compiling with the package", func(assert bdd.Assert) {
+				assert.NoError(typeCheck(dir))
+			})
+			it("should generate the file on package models", func(assert bdd.Assert) {
+				assert.True(strings.HasPrefix(src, "// Code generated by mongogen. DO NOT EDIT.\n\npackage models\n"))
+			})
+			it("should generate collection names and field name constants", func(assert bdd.Assert) {
+				assert.Contains(src, `ProductCollection = "products"`)
+				assert.Contains(src, `ProductName = "name"`)
+				assert.Contains(src, `ProductPrice = "price"`)
+				assert.Contains(src, `ItemID = "_id"`)
+				assert.Contains(src, `ItemTitle = "title"`)
+				assert.False(strings.Contains(src, "ProductSecret"))
+				assert.False(strings.Contains(src, "ProductExtra"))
+			})
+			it("should generate the indexes declared", func(assert bdd.Assert) {
+				assert.Contains(src, `{Key: []string{"name"}, Unique: true},`)
+				assert.Contains(src, `{Key: []string{"-price"}, Sparse: true},`)
+				assert.Contains(src, `{Key: []string{"name", "-price"}, Unique: true},`)
+				assert.Contains(src, `{Key: []string{"title"}},`)
+			})
+			it("should generate the typed handles", func(assert bdd.Assert) {
+				assert.Contains(src, "type ProductHandle = mongo.TypedHandle[*Product]")
+				assert.Contains(src, "h = mongo.NewTypedHandle(ItemCollection, &Item{}, ItemIndexes...)")
+			})
+			it("should generate the Documenter methods not declared", func(assert bdd.Assert) {
+				assert.Contains(src, "func (p *Product) Map() (out mongo.M, err error) {")
+				assert.Contains(src, "id = p.IDV")
+				assert.False(strings.Contains(src, "func (p *Product) Validate()"))
+			})
+			it("should generate only New for documents embedding Document", func(assert bdd.Assert) {
+				assert.Contains(src, "created.Bind(created)")
+				assert.False(strings.Contains(src, "func (i *Item) Map()"))
+			})
+		})
+	})
+
+	given(t, "a package with the source %[1]q", func(when bdd.When, args ...interface{}) {
+		dir := writeModels(t, args[0].(string))
+
+		when("run(dir) is called", func(it bdd.It) {
+			err := run(dir)
+
+			it("should return an error containing %[2]q", func(assert bdd.Assert) {
+				assert.Error(err)
+				if err != nil {
+					assert.Contains(err.Error(), args[1].(string))
+				}
+			})
+		})
+	}, like(
+		s("package models\n\ntype Tag struct{}\n", "no struct marked"),
+		s("package models\n\n//mongo:document tags\ntype Tag string\n", "isn't a struct"),
+		s("package models\n\n//mongo:document\ntype Tag struct{}\n", "needs a single collection name"),
+		s("package models\n\n//mongo:document tags\ntype Tag struct{}\n", "no field with bson key _id"),
+		s("package models\n\n//mongo:document tags\ntype Tag struct {\n\tID string `bson:\"_id\" mongo:\"primary\"`\n}\n", `unknown option "primary"`),
+		s("package models\n\n//mongo:document tags\n//mongo:index name first\ntype Tag struct {\n\tID string `bson:\"_id\"`\n\tC int64 `bson:\"created_on\"`\n\tU int64 `bson:\"updated_on\"`\n}\n", `unknown option "first"`),
+		s("package models\n\n//mongo:document tags\ntype Tag struct {\n\tID string `bson:\"_id\"`\n\tC int64 `bson:\"created_on\"`\n\tU int64 `bson:\"updated_on\"`\n\tName string `bson:\"name\"`\n\tNameV string `bson:\"name_v\"`\n}\n",
+			"name TagName generated for field NameV collides with the one generated for field Name of type Tag"),
+		s("package models\n\n//mongo:document tags\ntype Tag struct {\n\tID string `bson:\"_id\"`\n\tC int64 `bson:\"created_on\"`\n\tU int64 `bson:\"updated_on\"`\n\tCollection string `bson:\"collection\"`\n}\n",
+			"name TagCollection generated for field Collection collides with the one generated for the collection name of type Tag"),
+		s("package models\n\n//mongo:document tags\ntype Tag struct {\n\tID string `bson:\"_id\"`\n\tC int64 `bson:\"created_on\"`\n\tU int64 `bson:\"updated_on\"`\n\tHandleV string `bson:\"handle\"`\n}\n",
+			"name TagHandle generated for field HandleV collides"),
+		s("package models\n\n//mongo:document tags\ntype Tag struct {\n\tID string `bson:\"_id\"`\n\tC int64 `bson:\"created_on\"`\n\tU int64 `bson:\"updated_on\"`\n}\n\nvar TagIndexes []string\n",
+			"name TagIndexes generated for the indexes collides with a declaration of package"),
+	))
+}
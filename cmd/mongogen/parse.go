@@ -0,0 +1,504 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	// mongoPath it's the import path of mongo package, looked for on
+	// embedded Document fields.
+	mongoPath = "github.com/ddspog/mongo"
+	// documentDirective marks the structs to generate code for, followed
+	// by the collection name.
+	documentDirective = "//mongo:document"
+	// indexDirective declares a compound index on a struct marked,
+	// followed by keys separated by comma and index options.
+	indexDirective = "//mongo:index"
+	// generatedSuffix it's the suffix of files generated.
+	generatedSuffix = "_mongo.go"
+)
+
+// pkg it's a Go package parsed, with the models found on each file.
+type pkg struct {
+	Name   string
+	Files  map[string][]*model
+	Method map[string]map[string]bool
+	Idents map[string]bool
+}
+
+// model it's a struct marked with the document directive.
+type model struct {
+	Name       string
+	Receiver   string
+	Collection string
+	Embedded   bool
+	IDField    string
+	CreatedOn  string
+	UpdatedOn  string
+	Fields     []field
+	Indexes    []index
+	Declared   map[string]bool
+}
+
+// field it's a field of a model stored on documents.
+type field struct {
+	Name  string
+	Const string
+	Key   string
+}
+
+// generatedName it's a package level name generated for a model, with
+// what it's generated for.
+type generatedName struct {
+	Name string
+	Of   string
+}
+
+// index it's an index declared on a model.
+type index struct {
+	Keys   []string
+	Unique bool
+	Sparse bool
+}
+
+// Generates reports if method must be generated.
+func (m *model) Generates(method string) (ok bool) {
+	ok = !m.Declared[method]
+	return
+}
+
+// parseDir parses the Go files on dir, ignoring tests and files
+// generated, returning the models found.
+func parseDir(dir string) (p *pkg, err error) {
+	var names []string
+	if names, err = filepath.Glob(filepath.Join(dir, "*.go")); err == nil {
+		sort.Strings(names)
+
+		fset := token.NewFileSet()
+		p = &pkg{
+			Files:  make(map[string][]*model),
+			Method: make(map[string]map[string]bool),
+			Idents: make(map[string]bool),
+		}
+
+		var files []*ast.File
+		for i := 0; i < len(names) && err == nil; i++ {
+			if skipFile(names[i]) {
+				continue
+			}
+
+			var f *ast.File
+			if f, err = parser.ParseFile(fset, names[i], nil, parser.ParseComments); err == nil {
+				if p.Name == "" {
+					p.Name = f.Name.Name
+				}
+				p.collectMethods(f)
+				p.collectIdents(f)
+				files = append(files, f)
+			}
+		}
+
+		for i := 0; i < len(files) && err == nil; i++ {
+			var models []*model
+			if models, err = p.parseFile(files[i]); err == nil && len(models) > 0 {
+				p.Files[fset.Position(files[i].Pos()).Filename] = models
+			}
+		}
+	}
+
+	if err == nil && len(p.Files) == 0 {
+		err = fmt.Errorf("no struct marked with %s found on %s", documentDirective, dir)
+	}
+
+	if err == nil {
+		err = p.checkNames()
+	}
+
+	return
+}
+
+// skipFile reports if file name must be ignored when parsing.
+func skipFile(name string) (skip bool) {
+	skip = strings.HasSuffix(name, "_test.go") || strings.HasSuffix(name, generatedSuffix)
+	return
+}
+
+// collectMethods stores the methods declared on file f, by receiver
+// type name.
+func (p *pkg) collectMethods(f *ast.File) {
+	for _, decl := range f.Decls {
+		if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv != nil && len(fn.Recv.List) == 1 {
+			typ := fn.Recv.List[0].Type
+			if star, ok := typ.(*ast.StarExpr); ok {
+				typ = star.X
+			}
+			if id, ok := typ.(*ast.Ident); ok {
+				if p.Method[id.Name] == nil {
+					p.Method[id.Name] = make(map[string]bool)
+				}
+				p.Method[id.Name][fn.Name.Name] = true
+			}
+		}
+	}
+}
+
+// collectIdents stores the package level identifiers declared on file
+// f, except methods.
+func (p *pkg) collectIdents(f *ast.File) {
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Recv == nil {
+				p.Idents[d.Name.Name] = true
+			}
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch sp := spec.(type) {
+				case *ast.TypeSpec:
+					p.Idents[sp.Name.Name] = true
+				case *ast.ValueSpec:
+					for _, n := range sp.Names {
+						p.Idents[n.Name] = true
+					}
+				}
+			}
+		}
+	}
+}
+
+// checkNames checks that the names generated for the models don't
+// collide between themselves, nor with the identifiers declared on
+// package.
+func (p *pkg) checkNames() (err error) {
+	files := make([]string, 0, len(p.Files))
+	for name := range p.Files {
+		files = append(files, name)
+	}
+	sort.Strings(files)
+
+	used := make(map[string]string)
+	for i := 0; i < len(files) && err == nil; i++ {
+		models := p.Files[files[i]]
+		for j := 0; j < len(models) && err == nil; j++ {
+			m := models[j]
+			names := m.generatedNames()
+			for k := 0; k < len(names) && err == nil; k++ {
+				n := names[k]
+				if other, ok := used[n.Name]; ok {
+					err = fmt.Errorf("type %s: name %s generated for %s collides with the one generated for %s", m.Name, n.Name, n.Of, other)
+				} else if p.Idents[n.Name] {
+					err = fmt.Errorf("type %s: name %s generated for %s collides with a declaration of package", m.Name, n.Name, n.Of)
+				} else {
+					used[n.Name] = fmt.Sprintf("%s of type %s", n.Of, m.Name)
+				}
+			}
+		}
+	}
+
+	return
+}
+
+// generatedNames returns the package level names generated for model.
+func (m *model) generatedNames() (names []generatedName) {
+	names = []generatedName{
+		{m.Name + "Collection", "the collection name"},
+		{m.Name + "Indexes", "the indexes"},
+		{m.Name + "Handle", "the typed Handle"},
+		{"New" + m.Name + "Handle", "the Handle constructor"},
+	}
+	for _, f := range m.Fields {
+		names = append(names, generatedName{f.Const, "field " + f.Name})
+	}
+	return
+}
+
+// parseFile returns the models marked on file f.
+func (p *pkg) parseFile(f *ast.File) (models []*model, err error) {
+	mongoName := importName(f, mongoPath)
+
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+
+		for i := 0; i < len(gen.Specs) && err == nil; i++ {
+			spec := gen.Specs[i].(*ast.TypeSpec)
+			doc := spec.Doc
+			if doc == nil && len(gen.Specs) == 1 {
+				doc = gen.Doc
+			}
+
+			var m *model
+			if m, err = parseModel(spec, doc, mongoName); err == nil && m != nil {
+				m.Declared = p.Method[m.Name]
+				models = append(models, m)
+			}
+		}
+	}
+
+	return
+}
+
+// importName returns the name used on file f for the package imported
+// with path, or an empty string if it's not imported.
+func importName(f *ast.File, path string) (name string) {
+	for _, imp := range f.Imports {
+		if p, err := strconv.Unquote(imp.Path.Value); err == nil && p == path {
+			name = filepath.Base(path)
+			if imp.Name != nil {
+				name = imp.Name.Name
+			}
+		}
+	}
+	return
+}
+
+// parseModel returns the model declared by spec, if it's a struct
+// marked with the document directive on its doc comment.
+func parseModel(spec *ast.TypeSpec, doc *ast.CommentGroup, mongoName string) (m *model, err error) {
+	var collection string
+	var indexes []string
+	var marked bool
+
+	if doc != nil {
+		for _, c := range doc.List {
+			if args, ok := directive(c.Text, documentDirective); ok {
+				marked, collection = true, args
+			} else if args, ok := directive(c.Text, indexDirective); ok {
+				indexes = append(indexes, args)
+			}
+		}
+	}
+
+	if marked {
+		st, ok := spec.Type.(*ast.StructType)
+		switch {
+		case !ok:
+			err = fmt.Errorf("type %s: %s used on a type that isn't a struct", spec.Name.Name, documentDirective)
+		case collection == "" || strings.ContainsAny(collection, " \t"):
+			err = fmt.Errorf("type %s: %s needs a single collection name", spec.Name.Name, documentDirective)
+		default:
+			m = &model{
+				Name:       spec.Name.Name,
+				Receiver:   receiver(spec.Name.Name),
+				Collection: collection,
+			}
+			if err = m.parseFields(st, mongoName); err == nil {
+				for i := 0; i < len(indexes) && err == nil; i++ {
+					err = m.parseIndexDirective(indexes[i])
+				}
+			}
+		}
+	}
+
+	if err != nil {
+		m = nil
+	}
+
+	return
+}
+
+// directive returns the arguments of comment c, if it's the directive
+// name received.
+func directive(c, name string) (args string, ok bool) {
+	if ok = c == name || strings.HasPrefix(c, name+" "); ok {
+		args = strings.TrimSpace(strings.TrimPrefix(c, name))
+	}
+	return
+}
+
+// parseFields reads the fields of struct st onto model, with the index
+// options declared on their mongo tags.
+func (m *model) parseFields(st *ast.StructType, mongoName string) (err error) {
+	for _, f := range st.Fields.List {
+		var tag reflect.StructTag
+		if f.Tag != nil {
+			if tv, errTag := strconv.Unquote(f.Tag.Value); errTag == nil {
+				tag = reflect.StructTag(tv)
+			}
+		}
+
+		// Fields inlined store their own fields, so only an inlined
+		// Document has keys known.
+		if len(f.Names) == 0 || inlined(tag) {
+			if isDocument(f.Type, mongoName) {
+				m.Embedded = true
+				m.Fields = append(m.Fields,
+					field{Name: "IDV", Const: m.Name + "ID", Key: "_id"},
+					field{Name: "CreatedOnV", Const: m.Name + "CreatedOn", Key: "created_on"},
+					field{Name: "UpdatedOnV", Const: m.Name + "UpdatedOn", Key: "updated_on"},
+				)
+			}
+			continue
+		}
+
+		for i := 0; i < len(f.Names) && err == nil; i++ {
+			name := f.Names[i].Name
+			if !ast.IsExported(name) {
+				continue
+			}
+
+			key := bsonKey(name, tag)
+			if key == "-" {
+				continue
+			}
+
+			m.Fields = append(m.Fields, field{Name: name, Const: m.Name + strings.TrimSuffix(name, "V"), Key: key})
+			switch key {
+			case "_id":
+				m.IDField = name
+			case "created_on":
+				m.CreatedOn = name
+			case "updated_on":
+				m.UpdatedOn = name
+			}
+
+			err = m.parseIndexTag(name, key, tag.Get("mongo"))
+		}
+
+		if err != nil {
+			break
+		}
+	}
+
+	if err == nil && !m.Embedded {
+		switch {
+		case m.IDField == "":
+			err = fmt.Errorf("type %s: no field with bson key _id", m.Name)
+		case m.CreatedOn == "":
+			err = fmt.Errorf("type %s: no field with bson key created_on", m.Name)
+		case m.UpdatedOn == "":
+			err = fmt.Errorf("type %s: no field with bson key updated_on", m.Name)
+		}
+	}
+
+	return
+}
+
+// parseIndexTag adds the index declared by the mongo tag of field name,
+// stored with key. The tag accepts the options index, unique, sparse
+// and desc, separated by comma.
+func (m *model) parseIndexTag(name, key, tag string) (err error) {
+	if tag != "" {
+		var idx index
+		var indexed, desc bool
+
+		for _, opt := range strings.Split(tag, ",") {
+			switch strings.TrimSpace(opt) {
+			case "index":
+				indexed = true
+			case "unique":
+				indexed, idx.Unique = true, true
+			case "sparse":
+				indexed, idx.Sparse = true, true
+			case "desc":
+				indexed, desc = true, true
+			default:
+				err = fmt.Errorf("type %s: unknown option %q on mongo tag of field %s", m.Name, opt, name)
+			}
+		}
+
+		if err == nil && indexed {
+			if desc {
+				key = "-" + key
+			}
+			idx.Keys = []string{key}
+			m.Indexes = append(m.Indexes, idx)
+		}
+	}
+
+	return
+}
+
+// parseIndexDirective adds the index declared by the arguments of an
+// index directive: keys separated by comma, followed by the options
+// unique and sparse.
+func (m *model) parseIndexDirective(args string) (err error) {
+	parts := strings.Fields(args)
+	if len(parts) == 0 {
+		err = fmt.Errorf("type %s: %s needs the index keys", m.Name, indexDirective)
+	} else {
+		idx := index{Keys: strings.Split(parts[0], ",")}
+		for i := 0; i < len(idx.Keys) && err == nil; i++ {
+			if k := strings.TrimPrefix(idx.Keys[i], "-"); k == "" {
+				err = fmt.Errorf("type %s: empty key on %s", m.Name, indexDirective)
+			}
+		}
+
+		for i := 1; i < len(parts) && err == nil; i++ {
+			switch parts[i] {
+			case "unique":
+				idx.Unique = true
+			case "sparse":
+				idx.Sparse = true
+			default:
+				err = fmt.Errorf("type %s: unknown option %q on %s", m.Name, parts[i], indexDirective)
+			}
+		}
+
+		if err == nil {
+			m.Indexes = append(m.Indexes, idx)
+		}
+	}
+
+	return
+}
+
+// isDocument reports if expr refers to mongo.Document, imported with
+// mongoName.
+func isDocument(expr ast.Expr, mongoName string) (ok bool) {
+	if sel, isSel := expr.(*ast.SelectorExpr); isSel && mongoName != "" {
+		if id, isIdent := sel.X.(*ast.Ident); isIdent {
+			ok = id.Name == mongoName && sel.Sel.Name == "Document"
+		}
+	}
+	return
+}
+
+// bsonKey returns the key used by bson to store the field name, with
+// tag received.
+func bsonKey(name string, tag reflect.StructTag) (key string) {
+	if key = strings.Split(bsonTag(tag), ",")[0]; key == "" {
+		key = strings.ToLower(name)
+	}
+	return
+}
+
+// inlined reports if tag marks the field as inlined by bson.
+func inlined(tag reflect.StructTag) (ok bool) {
+	flags := strings.Split(bsonTag(tag), ",")[1:]
+	for i := 0; i < len(flags) && !ok; i++ {
+		ok = flags[i] == "inline"
+	}
+	return
+}
+
+// bsonTag returns the bson options on tag, which can be the whole tag
+// when it has no keys.
+func bsonTag(tag reflect.StructTag) (s string) {
+	s, ok := tag.Lookup("bson")
+	if !ok && !strings.Contains(string(tag), ":") {
+		s = string(tag)
+	}
+	return
+}
+
+// receiver returns the receiver name used on methods of type name: its
+// first letter, or its name when the letter is used on methods
+// generated.
+func receiver(name string) (r string) {
+	if r = string(unicode.ToLower([]rune(name)[0])); r == "t" {
+		r = strings.ToLower(name[:1]) + name[1:]
+	}
+	return
+}
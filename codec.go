@@ -0,0 +1,87 @@
+package mongo
+
+import (
+	"reflect"
+
+	"github.com/ddspog/mongo/internal/bsonutils"
+	"github.com/globalsign/mgo/bson"
+)
+
+// Raw represents raw unprocessed BSON values, received by the
+// DecodeFunc registered for custom types. Its Unmarshal method decodes
+// it onto any value.
+type Raw = bson.Raw
+
+// EncodeFunc it's a function translating the value v, of a registered
+// type, to the value stored on its place. It can return any value
+// stored on documents, and nil to store null.
+type EncodeFunc = bsonutils.EncodeFunc
+
+// DecodeFunc it's a function decoding the raw value stored onto out, a
+// settable value of a registered type. Returning a *bson.TypeError
+// skips the field, while other errors abort the decoding.
+type DecodeFunc = bsonutils.DecodeFunc
+
+// registry it's the registry of custom types, used to translate
+// documents.
+var registry = bsonutils.NewRegistry()
+
+// RegisterType registers the functions encoding and decoding values of
+// type t on documents, replacing the ones registered before. They're
+// consulted before the built-in kinds on fields, map values and keys,
+// and slice elements, including pointers to t. Any of them can be nil,
+// to keep the built-in behavior on that direction.
+//
+// It can be used like this:
+//
+//	mongo.RegisterType(reflect.TypeOf(Money{}), func(v reflect.Value) (interface{}, error) {
+//		return v.Interface().(Money).String(), nil
+//	}, func(raw mongo.Raw, out reflect.Value) (err error) {
+//		var s string
+//		if err = raw.Unmarshal(&s); err == nil {
+//			var m Money
+//			if m, err = ParseMoney(s); err == nil {
+//				out.Set(reflect.ValueOf(m))
+//			}
+//		}
+//		return
+//	})
+//
+// Values on search maps and update operators are encoded by the driver,
+// without the functions registered.
+func RegisterType(t reflect.Type, enc EncodeFunc, dec DecodeFunc) {
+	registry.RegisterType(t, enc, dec)
+}
+
+// RegisterInterface registers the functions encoding and decoding
+// values of types implementing the interface t on documents, like
+// RegisterType does. Values are encoded when their dynamic type
+// implements t, and decoded when their type or a pointer to it
+// implements t. Types registered with RegisterType, and interfaces
+// registered first, take precedence. It panics if t isn't an
+// interface.
+func RegisterInterface(t reflect.Type, enc EncodeFunc, dec DecodeFunc) {
+	registry.RegisterInterface(t, enc, dec)
+}
+
+// MarshalExtJSON returns the in value, a document or a map, as MongoDB
+// Extended JSON v2, translated the same way documents are stored:
+// omitting empty fields and using the custom types registered. On
+// canonical mode every value keeps its BSON type, while on relaxed mode
+// numbers and dates use native JSON values, being easier to read.
+func MarshalExtJSON(in interface{}, canonical bool) (out []byte, err error) {
+	out, err = bsonutils.MarshalExtJSONWith(documentOptions, in, canonical)
+	return
+}
+
+// UnmarshalExtJSON decodes the MongoDB Extended JSON v2 document on
+// data, on canonical or relaxed mode, onto the out value, a pointer to
+// a document or a map. Documents are bound to the Document they embed.
+func UnmarshalExtJSON(data []byte, out interface{}) (err error) {
+	if err = bsonutils.UnmarshalExtJSONWith(documentOptions, data, out); err == nil {
+		if d, ok := out.(Documenter); ok {
+			bind(d)
+		}
+	}
+	return
+}
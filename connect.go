@@ -2,6 +2,8 @@ package mongo
 
 //noinspection GoInvalidPackageImport
 import (
+	"context"
+
 	"github.com/ddspog/mongo/internal/connecter"
 	"github.com/globalsign/mgo"
 )
@@ -10,6 +12,11 @@ import (
 // database of a temporary database.
 type Connecter = connecter.MongoConnecter
 
+// ContextConnecter it's an optional interface of Connecter, used by
+// ConnectCtx on connecters able to connect stopping when a context is
+// done.
+type ContextConnecter = connecter.ContextConnecter
+
 var (
 	// NewConnecter returns a new real database connecter.
 	NewConnecter = connecter.New
@@ -37,6 +44,19 @@ func Connect() (err error) {
 	return
 }
 
+// ConnectCtx connects to MongoDB of server like Connect, stopping when
+// ctx is done. The deadline of ctx limits the time to dial the server.
+// Connecters not implementing ConnectCtx just connect with Connect, if
+// ctx isn't done yet.
+func ConnectCtx(ctx context.Context) (err error) {
+	if c, ok := conn.(ContextConnecter); ok {
+		err = c.ConnectCtx(ctx)
+	} else if err = ctx.Err(); err == nil {
+		err = conn.Connect()
+	}
+	return
+}
+
 // Disconnect undo the connection made. Preparing package for a new
 // connection.
 func Disconnect() {
@@ -0,0 +1,455 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"time"
+
+	"github.com/globalsign/mgo"
+)
+
+// ErrNotConnected it's an error received when running an operation
+// with a context, without a connection to MongoDB.
+var ErrNotConnected = errors.New("not connected to MongoDB")
+
+// errAborted it's the error of an operation aborted by the session
+// closed when its context is done.
+var errAborted = errors.New("operation aborted")
+
+// sessionClosed it's the value mgo panics with, when using a closed
+// session.
+const sessionClosed = "Session already closed"
+
+// CountCtx works like Count, stopping when ctx is done. The deadline of
+// ctx limits the time the count runs on server.
+func (h *Handle) CountCtx(ctx context.Context, opts ...CountOptions) (n int, err error) {
+	var opt CountOptions
+	if len(opts) == 1 {
+		opt = opts[0]
+	}
+	opt.MaxTime = deadlineMaxTime(ctx, opt.MaxTime)
+
+	var found int
+	if err = h.withContext(ctx, func(hc *Handle) (err error) {
+		found, err = hc.Count(opt)
+		return
+	}); err == nil {
+		n = found
+	}
+
+	return
+}
+
+// DistinctCtx works like Distinct, stopping when ctx is done. The
+// result is only decoded onto result when the operation ends.
+func (h *Handle) DistinctCtx(ctx context.Context, field string, result interface{}, collation ...*mgo.Collation) (err error) {
+	err = h.withResult(ctx, result, func(hc *Handle, out interface{}) error {
+		return hc.Distinct(field, out, collation...)
+	})
+	return
+}
+
+// FindCtx works like Find, stopping when ctx is done. The deadline of
+// ctx limits the time the query runs on server.
+func (h *Handle) FindCtx(ctx context.Context, opts ...QueryOptions) (out Documenter, err error) {
+	opt := deadlineOptions(ctx, opts...)
+
+	var found Documenter
+	if err = h.withContext(ctx, func(hc *Handle) (err error) {
+		found, err = hc.Find(opt)
+		return
+	}); err == nil {
+		out = found
+	}
+
+	return
+}
+
+// FindAllCtx works like FindAll, stopping when ctx is done. The
+// deadline of ctx limits the time the query runs on server.
+func (h *Handle) FindAllCtx(ctx context.Context, opts ...QueryOptions) (out []Documenter, err error) {
+	opt := deadlineOptions(ctx, opts...)
+
+	var found []Documenter
+	if err = h.withContext(ctx, func(hc *Handle) (err error) {
+		found, err = hc.FindAll(opt)
+		return
+	}); err == nil {
+		out = found
+	}
+
+	return
+}
+
+// FindPageCtx works like FindPage, stopping when ctx is done. The
+// deadline of ctx limits the time the query runs on server.
+func (h *Handle) FindPageCtx(ctx context.Context, opts ...QueryOptions) (out []Documenter, total int, err error) {
+	opt := deadlineOptions(ctx, opts...)
+
+	var found []Documenter
+	var n int
+	if err = h.withContext(ctx, func(hc *Handle) (err error) {
+		found, n, err = hc.FindPage(opt)
+		return
+	}); err == nil {
+		out, total = found, n
+	}
+
+	return
+}
+
+// FindAfterCtx works like FindAfter, stopping when ctx is done. The
+// deadline of ctx limits the time the query runs on server.
+func (h *Handle) FindAfterCtx(ctx context.Context, token string, opts ...QueryOptions) (out []Documenter, next string, err error) {
+	opt := deadlineOptions(ctx, opts...)
+
+	var found []Documenter
+	var after string
+	if err = h.withContext(ctx, func(hc *Handle) (err error) {
+		found, after, err = hc.FindAfter(token, opt)
+		return
+	}); err == nil {
+		out, next = found, after
+	}
+
+	return
+}
+
+// EachCtx works like Each, stopping when ctx is done. The deadline of
+// ctx limits the time the query runs on server, and f isn't called
+// after ctx is done. Iter has no variant with context, use EachCtx
+// instead.
+func (h *Handle) EachCtx(ctx context.Context, f func(Documenter) error, opts ...QueryOptions) (err error) {
+	opt := deadlineOptions(ctx, opts...)
+
+	err = h.withContext(ctx, func(hc *Handle) error {
+		return hc.Each(func(d Documenter) (err error) {
+			if err = ctx.Err(); err == nil {
+				err = f(d)
+			}
+			return
+		}, opt)
+	})
+	return
+}
+
+// AggregateCtx works like Aggregate, stopping when ctx is done. The
+// deadline of ctx limits the time the pipeline runs on server.
+func (h *Handle) AggregateCtx(ctx context.Context, p *Pipeline, opts ...AggregateOptions) (out []Documenter, err error) {
+	opt := deadlineAggregateOptions(ctx, opts...)
+
+	var found []Documenter
+	if err = h.withContext(ctx, func(hc *Handle) (err error) {
+		found, err = hc.Aggregate(p, opt)
+		return
+	}); err == nil {
+		out = found
+	}
+
+	return
+}
+
+// AggregateToCtx works like AggregateTo, stopping when ctx is done. The
+// deadline of ctx limits the time the pipeline runs on server, and the
+// results are only decoded onto result when the pipeline ends.
+func (h *Handle) AggregateToCtx(ctx context.Context, p *Pipeline, result interface{}, opts ...AggregateOptions) (err error) {
+	opt := deadlineAggregateOptions(ctx, opts...)
+
+	err = h.withResult(ctx, result, func(hc *Handle, out interface{}) error {
+		return hc.AggregateTo(p, out, opt)
+	})
+	return
+}
+
+// InsertCtx works like Insert, stopping when ctx is done.
+func (h *Handle) InsertCtx(ctx context.Context) (err error) {
+	err = h.withContext(ctx, func(hc *Handle) error {
+		return hc.Insert()
+	})
+	return
+}
+
+// InsertManyCtx works like InsertMany, stopping when ctx is done.
+func (h *Handle) InsertManyCtx(ctx context.Context, docs []Documenter) (result *BulkResult, err error) {
+	ops := make([]BulkOperation, len(docs))
+	for i := range docs {
+		ops[i] = BulkInsert(docs[i])
+	}
+
+	result, err = h.BulkWriteCtx(ctx, true, ops...)
+	return
+}
+
+// BulkWriteCtx works like BulkWrite, stopping when ctx is done. The
+// documents of the operations are only changed when the bulk ends.
+func (h *Handle) BulkWriteCtx(ctx context.Context, ordered bool, ops ...BulkOperation) (result *BulkResult, err error) {
+	detached := make([]BulkOperation, len(ops))
+	for i := range ops {
+		detached[i] = ops[i]
+		detached[i].document = detach(ops[i].document)
+	}
+
+	var written *BulkResult
+	err = h.withContext(ctx, func(hc *Handle) (err error) {
+		written, err = hc.BulkWrite(ordered, detached...)
+		return
+	}, func() {
+		for i := range ops {
+			attach(ops[i].document, detached[i].document)
+		}
+		result = written
+	})
+
+	if result == nil {
+		result = &BulkResult{}
+	}
+
+	return
+}
+
+// UpdateCtx works like Update, stopping when ctx is done.
+func (h *Handle) UpdateCtx(ctx context.Context, id ObjectId) (err error) {
+	err = h.withContext(ctx, func(hc *Handle) error {
+		return hc.Update(id)
+	})
+	return
+}
+
+// UpdateWithCtx works like UpdateWith, stopping when ctx is done.
+func (h *Handle) UpdateWithCtx(ctx context.Context, id ObjectId, ops *Ops) (err error) {
+	err = h.withContext(ctx, func(hc *Handle) error {
+		return hc.UpdateWith(id, ops)
+	})
+	return
+}
+
+// UpdateAllCtx works like UpdateAll, stopping when ctx is done.
+func (h *Handle) UpdateAllCtx(ctx context.Context, update interface{}) (info *mgo.ChangeInfo, err error) {
+	var changed *mgo.ChangeInfo
+	if err = h.withContext(ctx, func(hc *Handle) (err error) {
+		changed, err = hc.UpdateAll(update)
+		return
+	}); err == nil {
+		info = changed
+	}
+
+	return
+}
+
+// UpdateAllWithCtx works like UpdateAllWith, stopping when ctx is done.
+func (h *Handle) UpdateAllWithCtx(ctx context.Context, ops *Ops) (info *mgo.ChangeInfo, err error) {
+	info, err = h.UpdateAllCtx(ctx, ops)
+	return
+}
+
+// UpdateChangesCtx works like UpdateChanges, stopping when ctx is done.
+func (h *Handle) UpdateChangesCtx(ctx context.Context, id ObjectId, original ...Documenter) (err error) {
+	detached := make([]Documenter, len(original))
+	for i := range original {
+		detached[i] = detach(original[i])
+	}
+
+	err = h.withContext(ctx, func(hc *Handle) error {
+		return hc.UpdateChanges(id, detached...)
+	})
+	return
+}
+
+// UpsertCtx works like Upsert, stopping when ctx is done.
+func (h *Handle) UpsertCtx(ctx context.Context) (created bool, id ObjectId, err error) {
+	var inserted bool
+	var upserted ObjectId
+	if err = h.withContext(ctx, func(hc *Handle) (err error) {
+		inserted, upserted, err = hc.Upsert()
+		return
+	}); err == nil {
+		created, id = inserted, upserted
+	}
+
+	return
+}
+
+// FindAndModifyCtx works like FindAndModify, stopping when ctx is done.
+func (h *Handle) FindAndModifyCtx(ctx context.Context, opts ModifyOptions) (out Documenter, info *mgo.ChangeInfo, err error) {
+	var found Documenter
+	var changed *mgo.ChangeInfo
+	if err = h.withContext(ctx, func(hc *Handle) (err error) {
+		found, changed, err = hc.FindAndModify(opts)
+		return
+	}); err == nil {
+		out, info = found, changed
+	}
+
+	return
+}
+
+// RemoveCtx works like Remove, stopping when ctx is done.
+func (h *Handle) RemoveCtx(ctx context.Context, id ObjectId) (err error) {
+	err = h.withContext(ctx, func(hc *Handle) error {
+		return hc.Remove(id)
+	})
+	return
+}
+
+// RemoveAllCtx works like RemoveAll, stopping when ctx is done.
+func (h *Handle) RemoveAllCtx(ctx context.Context) (info *mgo.ChangeInfo, err error) {
+	var changed *mgo.ChangeInfo
+	if err = h.withContext(ctx, func(hc *Handle) (err error) {
+		changed, err = hc.RemoveAll()
+		return
+	}); err == nil {
+		info = changed
+	}
+
+	return
+}
+
+// withContext runs the operation f on a copy of Handle, bound to a
+// database of a new DatabaseSocket, from DBCtx, with the deadline of
+// ctx as socket timeout. The functions on finish run after f returns.
+//
+// The copy of Handle holds a copy of its document, stored back on the
+// document of Handle when f returns. When ctx is done before it, the
+// socket is released, aborting the next steps of f, and the error of
+// ctx is returned once f returns, leaving the document of Handle
+// untouched. f always runs on the goroutine of caller.
+func (h *Handle) withContext(ctx context.Context, f func(hc *Handle) error, finish ...func()) (err error) {
+	defer h.ifSafelyClose()
+
+	if err = h.InternalErr; err == nil {
+		sk := NewSocket()
+
+		var db *mgo.Database
+		if db, err = sk.DBCtx(ctx); err == nil {
+			defer sk.Close()
+
+			if db == nil {
+				err = ErrNotConnected
+			} else {
+				err = h.detached(ctx, db, f, finish...)
+			}
+		}
+	}
+
+	if err == context.DeadlineExceeded {
+		err = &TimeoutError{Err: err}
+	}
+
+	return
+}
+
+// detached runs the operation f on a copy of Handle using db, on the
+// goroutine of caller. The session of db is closed when ctx is done,
+// aborting f on its next step, or on the socket timeout set by DBCtx.
+// The copy, and the functions on finish, are only applied when f isn't
+// aborted by ctx.
+func (h *Handle) detached(ctx context.Context, db *mgo.Database, f func(hc *Handle) error, finish ...func()) (err error) {
+	hc := *h
+	hc.safely = false
+	hc.socket = nil
+	hc.collection = h.collection.With(db.Session)
+	hc.DocumentV = detach(h.DocumentV)
+	hc.snapshots = h.snapshots.clone()
+
+	if err = runAbortable(&hc, f); err == errAborted || (err != nil && ctx.Err() != nil) {
+		err = ctx.Err()
+	} else {
+		attach(h.DocumentV, hc.DocumentV)
+		h.snapshots = hc.snapshots
+
+		for i := range finish {
+			finish[i]()
+		}
+	}
+
+	return
+}
+
+// runAbortable runs f on hc, returning errAborted if it panics using
+// the session closed when its context is done. Any other panic is
+// propagated to the caller.
+func runAbortable(hc *Handle, f func(hc *Handle) error) (err error) {
+	defer func() {
+		if r := recover(); r == sessionClosed {
+			err = errAborted
+		} else if r != nil {
+			panic(r)
+		}
+	}()
+
+	err = f(hc)
+	return
+}
+
+// withResult runs the operation f like withContext, decoding onto a
+// new value of the type pointed by result, set on result only when f
+// returns with no errors.
+func (h *Handle) withResult(ctx context.Context, result interface{}, f func(hc *Handle, out interface{}) error) (err error) {
+	out := result
+	if v := reflect.ValueOf(result); v.Kind() == reflect.Ptr && !v.IsNil() {
+		out = reflect.New(v.Type().Elem()).Interface()
+	}
+
+	if err = h.withContext(ctx, func(hc *Handle) error {
+		return f(hc, out)
+	}); err == nil && out != result {
+		reflect.ValueOf(result).Elem().Set(reflect.ValueOf(out).Elem())
+	}
+
+	return
+}
+
+// detach returns a copy of the document d, bound to its own Document,
+// when it's a pointer to a struct. Otherwise d itself is returned.
+func detach(d Documenter) (c Documenter) {
+	c = d
+	if v := reflect.ValueOf(d); v.Kind() == reflect.Ptr && !v.IsNil() && v.Elem().Kind() == reflect.Struct {
+		cv := reflect.New(v.Elem().Type())
+		cv.Elem().Set(v.Elem())
+
+		c = cv.Interface().(Documenter)
+		bind(c)
+	}
+	return
+}
+
+// attach stores the copy c, returned by detach, on the document d.
+func attach(d, c Documenter) {
+	if c != d {
+		reflect.ValueOf(d).Elem().Set(reflect.ValueOf(c).Elem())
+		bind(d)
+	}
+}
+
+// deadlineOptions returns the query options received, limiting the
+// time the query runs on server by the deadline of ctx.
+func deadlineOptions(ctx context.Context, opts ...QueryOptions) (opt QueryOptions) {
+	if len(opts) == 1 {
+		opt = opts[0]
+	}
+	opt.MaxTime = deadlineMaxTime(ctx, opt.MaxTime)
+	return
+}
+
+// deadlineAggregateOptions returns the aggregate options received,
+// limiting the time the pipeline runs on server by the deadline of ctx.
+func deadlineAggregateOptions(ctx context.Context, opts ...AggregateOptions) (opt AggregateOptions) {
+	if len(opts) == 1 {
+		opt = opts[0]
+	}
+	opt.MaxTime = deadlineMaxTime(ctx, opt.MaxTime)
+	return
+}
+
+// deadlineMaxTime returns the time left until the deadline of ctx, or
+// maxTime if it's defined and shorter.
+func deadlineMaxTime(ctx context.Context, maxTime time.Duration) (d time.Duration) {
+	d = maxTime
+	if deadline, ok := ctx.Deadline(); ok {
+		if left := time.Until(deadline); d <= 0 || left < d {
+			d = left
+		}
+	}
+	return
+}
@@ -166,6 +166,27 @@ The Documenter can be used like this:
 	p.CalculateCreatedOn()
 	t := p.CreatedOn()
 
+Most of these methods can be avoided embedding Document inline, that
+implements them for the type embedding it. The type must be bound to
+its Document, what Handle does with documents received, or Map and
+Init return ErrDocumentNotBound:
+
+	type Product struct {
+		mongo.Document	`bson:",inline"`
+		NameV		string		`bson:"name"`
+		PriceV		float32		`bson:"price"`
+	}
+
+	func NewProduct() (p *Product) {
+		p = &Product{}
+		p.Bind(p)
+		return
+	}
+
+The command mongogen, on cmd/mongogen, can also generate these methods
+with go generate, along with a typed Handle, constants with the keys
+of fields and the indexes declared.
+
 Handle
 
 Mongo package also enable creation of Handle, a type that connects to
@@ -260,5 +281,91 @@ for the Document type:
 	}
 
 For all functions written, verification it's advisable.
+
+Repository
+
+Handle stores the document and search map used on operations, so it
+can't be shared between goroutines. When that's needed, like on HTTP
+servers, use a Repository, created with the same collection name,
+document and indexes used on NewHandle. It receives documents and
+filters on each call, using a new socket for each one:
+
+	var products = mongo.NewRepository(product.CollectionName, product.New(), product.CollectionIndexes...)
+
+	func handler(w http.ResponseWriter, r *http.Request) {
+		docs, err := products.FindAll(mongo.M{"name": r.FormValue("name")})
+		// ...
+	}
+
+For operations not available on Repository, Handle returns a Handle
+that isn't shared, closing after its first operation.
+
+Errors
+
+Operations of Handle translate driver errors to the errors of this
+package, checked with errors.Is and errors.As instead of comparing
+them, since most wrap the driver error:
+
+	_, err := p.SearchFor(mongo.M{"_id": id}).Find()
+	if errors.Is(err, mongo.ErrNotFound) {
+		// No document matches the search.
+	}
+
+	var dup *mongo.DuplicateKeyError
+	if err = p.Insert(); errors.As(err, &dup) {
+		// The index dup.Index was violated by the key dup.Key.
+	}
+
+ErrNotFound is mgo.ErrNotFound itself, while DuplicateKeyError,
+ValidationError, TimeoutError and ConnectionError unwrap to the error
+causing them.
+
+Contexts
+
+Operations of Handle have variants receiving a context, named with a
+Ctx suffix, like FindCtx, UpdateChangesCtx and BulkWriteCtx. Iterators
+have none, use EachCtx instead. ConnectCtx connects the same way:
+
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
+
+	doc, err := p.Safely().SearchFor(mongo.M{"_id": id}).FindCtx(ctx)
+
+The deadline of ctx limits the time queries, counts and pipelines run
+on server, and the socket timeout of the session used. When ctx is done
+first, the session is closed, aborting the operation on its next step,
+and the error of ctx is returned. A request already sent to server is
+only interrupted by the socket timeout, so operations always run on the
+goroutine of caller, returning after they stop. They run on copies of
+documents and results, so they're left untouched when aborted.
+
+TypedHandle
+
+The casts from Documenter needed on Handle results can be avoided
+with a TypedHandle, that takes and returns the document type itself:
+
+	p := mongo.NewTypedHandle(product.CollectionName, product.New().(*product.Product))
+	prod, err := p.Safely().SearchFor(mongo.M{"_id": id}).Find()
+	// prod is a *product.Product.
+
+Custom types
+
+Types needing their own representation on documents, like money or
+enumerations, can have encoders and decoders registered with
+RegisterType, or RegisterInterface for all types implementing an
+interface. They're used when documents are mapped and decoded,
+wherever the types appear: fields, slices and map keys or values:
+
+	mongo.RegisterType(reflect.TypeOf(Money{}), encodeMoney, decodeMoney)
+
+Extended JSON
+
+Documents can be exported and imported as MongoDB Extended JSON v2,
+for APIs, fixtures and debugging, with MarshalExtJSON and
+UnmarshalExtJSON. The canonical mode keeps the type of every value,
+while the relaxed one is easier to read:
+
+	js, err := mongo.MarshalExtJSON(prod, false)
+	// {"_id":{"$oid":"..."},"name":"bread","price":1.5}
 */
 package mongo
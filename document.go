@@ -0,0 +1,168 @@
+package mongo
+
+import (
+	"reflect"
+)
+
+// documentType it's the type of Document, looked for on the documents
+// bound.
+var documentType = reflect.TypeOf(Document{})
+
+// Document it's a struct implementing PartialDocumenter, meant to be
+// embedded on document types, so they only need to declare their own
+// fields. It must be embedded inline, and the document bound to it,
+// for New, Map and Init to work on the embedding type.
+//
+// Document can be used like this:
+//
+//	type Product struct {
+//		mongo.Document `bson:",inline"`
+//		Name           string  `bson:"name"`
+//		Price          float32 `bson:"price"`
+//	}
+//
+//	func NewProduct() (p *Product) {
+//		p = &Product{}
+//		p.Bind(p)
+//		return
+//	}
+//
+// Handle binds the documents it receives, and documents created with
+// New are already bound.
+type Document struct {
+	IDV        ObjectId `bson:"_id"`
+	CreatedOnV int64    `bson:"created_on"`
+	UpdatedOnV int64    `bson:"updated_on"`
+	self       Documenter
+	projection M
+}
+
+// binder it's a Documenter that can be bound to the document
+// embedding it.
+type binder interface {
+	Bind(Documenter)
+}
+
+// Bind sets d as the document embedding Document, used on New, Map and
+// Init. Documents not embedding Document are ignored, except Document
+// itself, when used alone.
+func (doc *Document) Bind(d Documenter) {
+	if embedded(d) == doc || d == Documenter(doc) {
+		doc.self = d
+	}
+}
+
+// New creates a new instance of the document embedding Document, bound
+// to its own Document. Returns a new Document bound to itself when it
+// isn't bound.
+func (doc *Document) New() (d Documenter) {
+	if doc.self == nil {
+		created := &Document{}
+		created.Bind(created)
+		d = created
+	} else {
+		d = reflect.New(reflect.TypeOf(doc.self).Elem()).Interface().(Documenter)
+		bind(d)
+	}
+	return
+}
+
+// Validate checks for problems on document. Document has none, the
+// embedding type must define its own Validate to check its fields.
+func (doc *Document) Validate() (err error) {
+	return
+}
+
+// Map translates the document embedding Document to a M object, more
+// easily read by mgo methods. Returns ErrDocumentNotBound when it isn't
+// bound, since its fields would be missing.
+func (doc *Document) Map() (out M, err error) {
+	if doc.self == nil {
+		err = ErrDocumentNotBound
+	} else {
+		out, err = MapDocumenter(doc.self)
+	}
+	return
+}
+
+// Init translates a M received, to the document embedding Document. It
+// fills the structure fields with the values of each key in the M
+// received. Returns ErrDocumentNotBound when it isn't bound.
+func (doc *Document) Init(in M) (err error) {
+	if self := doc.self; self == nil {
+		err = ErrDocumentNotBound
+	} else {
+		// Unmarshalling zeroes the document, unbinding it.
+		err = InitDocumenter(in, &self)
+		doc.self = self
+	}
+	return
+}
+
+// ID returns the _id attribute of a Document.
+func (doc *Document) ID() (id ObjectId) {
+	id = doc.IDV
+	return
+}
+
+// CreatedOn returns the created_on attribute of a Document.
+func (doc *Document) CreatedOn() (t int64) {
+	t = doc.CreatedOnV
+	return
+}
+
+// UpdatedOn returns the updated_on attribute of a Document.
+func (doc *Document) UpdatedOn() (t int64) {
+	t = doc.UpdatedOnV
+	return
+}
+
+// GenerateID creates a new id for a document.
+func (doc *Document) GenerateID() {
+	doc.IDV = NewID()
+}
+
+// CalculateCreatedOn update the created_on attribute with a value
+// corresponding to actual time.
+func (doc *Document) CalculateCreatedOn() {
+	doc.CreatedOnV = NowInMilli()
+}
+
+// CalculateUpdatedOn update the updated_on attribute with a value
+// corresponding to actual time.
+func (doc *Document) CalculateUpdatedOn() {
+	doc.UpdatedOnV = NowInMilli()
+}
+
+// SetProjection stores the projection used to find the document.
+func (doc *Document) SetProjection(proj M) {
+	doc.projection = proj
+}
+
+// Projection returns the projection used to find the document.
+func (doc *Document) Projection() (proj M) {
+	proj = doc.projection
+	return
+}
+
+// bind binds the document d to the Document it embeds, if any.
+func bind(d Documenter) {
+	if b, ok := d.(binder); ok {
+		b.Bind(d)
+	}
+}
+
+// embedded returns the Document embedded on the struct pointed by d,
+// or nil if there's none.
+func embedded(d Documenter) (doc *Document) {
+	if v := reflect.ValueOf(d); v.Kind() == reflect.Ptr && !v.IsNil() {
+		if v = v.Elem(); v.Kind() == reflect.Struct {
+			if f, ok := v.Type().FieldByName("Document"); ok && f.Anonymous && f.Type == documentType {
+				if fv, err := v.FieldByIndexErr(f.Index); err == nil {
+					doc = fv.Addr().Interface().(*Document)
+				}
+			}
+		}
+	}
+	return
+}
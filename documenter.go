@@ -4,6 +4,10 @@ package mongo
 // types used to store values on a MongoDB. It contains getters and
 // generates to important documents values: _id, created_on and
 // updated_on
+//
+// Documents found by Handle are decoded straight from BSON onto the
+// value returned by New, following its bson tags, so Init is only used
+// to translate M values.
 type Documenter interface {
 	New() Documenter
 	Validate() error
@@ -16,3 +20,30 @@ type Documenter interface {
 	CalculateCreatedOn()
 	CalculateUpdatedOn()
 }
+
+// PartialDocumenter it's a Documenter able to store the projection
+// used when it was found, making possible to distinguish documents
+// with only a subset of fields loaded.
+type PartialDocumenter interface {
+	Documenter
+	SetProjection(M)
+	Projection() M
+}
+
+// IsPartial checks if the document received was found using a
+// projection, and so may have some fields not loaded. Only documents
+// implementing PartialDocumenter can be identified as partial.
+func IsPartial(d Documenter) (partial bool) {
+	if pd, ok := d.(PartialDocumenter); ok {
+		partial = len(pd.Projection()) > 0
+	}
+	return
+}
+
+// setProjection stores the projection received on document d, if it
+// implements PartialDocumenter.
+func setProjection(d Documenter, proj M) {
+	if pd, ok := d.(PartialDocumenter); ok {
+		pd.SetProjection(proj)
+	}
+}
@@ -3,6 +3,8 @@
 package mongo
 
 import (
+	"fmt"
+	"reflect"
 	"testing"
 	"time"
 
@@ -212,3 +214,172 @@ func Test_Encoding_to_map_object(t *testing.T) {
 		s(id1), s(id2), s(id3),
 	))
 }
+
+// Feature Embed Document on document types
+// - As a developer,
+// - I want to embed Document on my document types,
+// - So that I only need to declare the fields of my documents.
+func Test_Embed_Document_on_document_types(t *testing.T) {
+	given, like, s := bdd.Sentences().All()
+
+	given(t, "an item i with id '%[1]s' and name '%[2]s', bound to its Document", func(when bdd.When, args ...interface{}) {
+		i := &item{NameV: args[1].(string)}
+		i.IDV = ObjectIdHex(args[0].(string))
+		i.Bind(i)
+
+		when("out, errMap := i.Map() is called", func(it bdd.It) {
+			out, errMap := i.Map()
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.NoError(errMap)
+			})
+			it("out should contain _id '%[1]s' and name '%[2]s'", func(assert bdd.Assert) {
+				assert.Equal(M{"_id": ObjectIdHex(args[0].(string)), "name": args[1].(string)}, out)
+			})
+		})
+
+		when("d := i.New() is initialized with i.Map()", func(it bdd.It) {
+			d := i.New()
+			m, _ := i.Map()
+			errInit := d.Init(m)
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.NoError(errInit)
+			})
+			it("d should be an item equal to i", func(assert bdd.Assert) {
+				assert.Equal(i.IDV, d.(*item).IDV)
+				assert.Equal(i.NameV, d.(*item).NameV)
+			})
+			it("d should stay bound to its Document", func(assert bdd.Assert) {
+				out, _ := d.Map()
+				assert.Equal(m, out)
+			})
+		})
+	}, like(
+		s(id1, "bread"), s(id2, "milk"), s(id3, "eggs"),
+	))
+
+	given(t, "an item i not bound, and a Handle h receiving it", func(when bdd.When) {
+		i := &item{NameV: "bread"}
+
+		when("i.Map() is called, before and after h.SetDocument(i)", func(it bdd.It) {
+			_, errBefore := i.Map()
+
+			h := &Handle{}
+			h.SetDocument(i)
+			after, errAfter := i.Map()
+
+			it("should return ErrDocumentNotBound before", func(assert bdd.Assert) {
+				assert.Equal(ErrDocumentNotBound, errBefore)
+			})
+			it("should return no errors after", func(assert bdd.Assert) {
+				assert.NoError(errAfter)
+			})
+			it("should map the item fields after", func(assert bdd.Assert) {
+				assert.Equal(M{"name": "bread"}, after)
+			})
+		})
+	})
+}
+
+// celsius it's a temperature stored as a string, with a codec
+// registered.
+type celsius float64
+
+// reading it's a document declaring a field of a registered type.
+type reading struct {
+	Document `bson:",inline"`
+	ValueV   celsius `bson:"value"`
+}
+
+// Feature Translate documents with custom types registered
+// - As a developer,
+// - I want to register encoders and decoders of my own types,
+// - So that documents store them the way I need.
+func Test_Translate_documents_with_custom_types_registered(t *testing.T) {
+	given := bdd.Sentences().Given()
+
+	RegisterType(reflect.TypeOf(celsius(0)), func(v reflect.Value) (interface{}, error) {
+		return fmt.Sprintf("%.1fC", v.Float()), nil
+	}, func(raw Raw, out reflect.Value) (err error) {
+		var s string
+		var c float64
+		if err = raw.Unmarshal(&s); err == nil {
+			if _, err = fmt.Sscanf(s, "%fC", &c); err == nil {
+				out.SetFloat(c)
+			}
+		}
+		return
+	})
+
+	given(t, "a reading r with value 21.5", func(when bdd.When) {
+		r := &reading{ValueV: 21.5}
+
+		when("out, err := MapDocumenter(r) is called", func(it bdd.It) {
+			out, err := MapDocumenter(r)
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.NoError(err)
+			})
+			it("should store the value returned by the encoder", func(assert bdd.Assert) {
+				assert.Equal(M{"value": "21.5C"}, out)
+			})
+		})
+
+		when("InitDocumenter(m, &d) is called with the map of r", func(it bdd.It) {
+			m, _ := MapDocumenter(r)
+
+			var d Documenter = &reading{}
+			err := InitDocumenter(m, &d)
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.NoError(err)
+			})
+			it("should decode the value with the decoder", func(assert bdd.Assert) {
+				assert.Equal(celsius(21.5), d.(*reading).ValueV)
+			})
+		})
+	})
+}
+
+// Feature Export and import documents as Extended JSON
+// - As a developer,
+// - I want to translate documents to and from Extended JSON,
+// - So that I can use them on APIs, fixtures and debugging.
+func Test_Export_and_import_documents_as_Extended_JSON(t *testing.T) {
+	given, like, s := bdd.Sentences().All()
+
+	given(t, "an item i with ID '%[1]s' and name 'bread'", func(when bdd.When, args ...interface{}) {
+		i := &item{NameV: "bread"}
+		i.IDV = ObjectIdHex(args[0].(string))
+
+		when("js, err := MarshalExtJSON(i, false) is called", func(it bdd.It) {
+			js, err := MarshalExtJSON(i, false)
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.NoError(err)
+			})
+			it("should return the Extended JSON without empty fields", func(assert bdd.Assert) {
+				assert.Equal(`{"_id":{"$oid":"`+args[0].(string)+`"},"name":"bread"}`, string(js))
+			})
+		})
+
+		when("UnmarshalExtJSON(js, d) is called with the Extended JSON of i", func(it bdd.It) {
+			js, _ := MarshalExtJSON(i, true)
+
+			d := &item{}
+			err := UnmarshalExtJSON(js, d)
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.NoError(err)
+			})
+			it("d should be an item equal to i, bound to its Document", func(assert bdd.Assert) {
+				assert.Equal(i.IDV, d.IDV)
+				out, _ := d.Map()
+				assert.Equal(M{"_id": i.IDV, "name": "bread"}, out)
+			})
+		})
+	}, like(
+		s(id1), s(id2),
+	))
+}
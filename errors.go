@@ -0,0 +1,197 @@
+package mongo
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/globalsign/mgo"
+)
+
+// ErrNotFound it's an error received when no document matches a
+// search, or the document to update or remove. It's the driver error
+// itself, returned as-is, so comparing with mgo.ErrNotFound still
+// works.
+var ErrNotFound = mgo.ErrNotFound
+
+// DuplicateKeyError it's an error received when a write violates an
+// unique index. Index and Key hold the index violated and the key
+// duplicated, as described by the database.
+type DuplicateKeyError struct {
+	Index string
+	Key   string
+	Err   error
+}
+
+// Error returns the description of DuplicateKeyError.
+func (e *DuplicateKeyError) Error() string {
+	if e.Index == "" {
+		return "duplicate key: " + e.Err.Error()
+	}
+	return fmt.Sprintf("duplicate key %s on index %q", e.Key, e.Index)
+}
+
+// Unwrap returns the driver error of DuplicateKeyError.
+func (e *DuplicateKeyError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError it's an error received when a document isn't valid.
+// Fields maps each invalid field to its problem. It can be returned by
+// Validate, otherwise the errors returned by Validate are kept on Err.
+type ValidationError struct {
+	Fields map[string]string
+	Err    error
+}
+
+// Error returns the description of ValidationError, listing the
+// problems of each field.
+func (e *ValidationError) Error() string {
+	problems := make([]string, 0, len(e.Fields)+1)
+	if e.Err != nil {
+		problems = append(problems, e.Err.Error())
+	}
+
+	fields := make([]string, 0, len(e.Fields))
+	for f := range e.Fields {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+
+	for _, f := range fields {
+		problems = append(problems, f+": "+e.Fields[f])
+	}
+
+	return "invalid document: " + strings.Join(problems, "; ")
+}
+
+// Unwrap returns the error returned by Validate, if any.
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// TimeoutError it's an error received when an operation exceeds its
+// time limit, on the server or on the connection.
+type TimeoutError struct {
+	Err error
+}
+
+// Error returns the description of TimeoutError.
+func (e *TimeoutError) Error() string {
+	return "timeout: " + e.Err.Error()
+}
+
+// Unwrap returns the driver error of TimeoutError.
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// ConnectionError it's an error received when the database can't be
+// reached, or the connection is lost during an operation.
+type ConnectionError struct {
+	Err error
+}
+
+// Error returns the description of ConnectionError.
+func (e *ConnectionError) Error() string {
+	return "connection error: " + e.Err.Error()
+}
+
+// Unwrap returns the driver error of ConnectionError.
+func (e *ConnectionError) Unwrap() error {
+	return e.Err
+}
+
+// dupKeyPattern matches the index and key on duplicate key messages.
+var dupKeyPattern = regexp.MustCompile(`index: (\S+) dup key: (\{.*\})`)
+
+// Driver messages of errors caused by lost connections.
+var connectionMessages = []string{
+	"no reachable servers",
+	"Closed explicitly",
+	"connection reset",
+	"broken pipe",
+}
+
+// wrapErr translates the driver error on err to one of the errors of
+// this package, when it's known. Errors already translated are kept.
+func wrapErr(err *error) {
+	switch e := *err; {
+	case e == nil || isTranslated(e):
+	case mgo.IsDup(e):
+		*err = duplicateKeyError(e)
+	case isTimeout(e):
+		*err = &TimeoutError{Err: e}
+	case isConnection(e):
+		*err = &ConnectionError{Err: e}
+	}
+}
+
+// validationErr translates the error returned by Validate to a
+// ValidationError, unless it's already one.
+func validationErr(err error) (out error) {
+	var verr *ValidationError
+	if out = err; err != nil && !errors.As(err, &verr) {
+		out = &ValidationError{Err: err}
+	}
+	return
+}
+
+// isTranslated checks if err it's one of the errors of this package.
+func isTranslated(err error) (translated bool) {
+	var dup *DuplicateKeyError
+	var timeout *TimeoutError
+	var conn *ConnectionError
+	translated = errors.Is(err, ErrNotFound) || errors.As(err, &dup) ||
+		errors.As(err, &timeout) || errors.As(err, &conn)
+	return
+}
+
+// duplicateKeyError creates a DuplicateKeyError from the driver error
+// received, reading the index and key from its message.
+func duplicateKeyError(err error) (dup *DuplicateKeyError) {
+	dup = &DuplicateKeyError{
+		Err: err,
+	}
+
+	msg := err.Error()
+	if berr, ok := err.(*mgo.BulkError); ok && len(berr.Cases()) > 0 {
+		msg = berr.Cases()[0].Err.Error()
+	}
+
+	if found := dupKeyPattern.FindStringSubmatch(msg); found != nil {
+		dup.Index, dup.Key = found[1], found[2]
+	}
+
+	return
+}
+
+// isTimeout checks if err was caused by a time limit exceeded, on the
+// network or on the server.
+func isTimeout(err error) (timeout bool) {
+	var nerr net.Error
+	if errors.As(err, &nerr) {
+		timeout = nerr.Timeout()
+	} else if qerr, ok := err.(*mgo.QueryError); ok {
+		timeout = qerr.Code == 50
+	} else if lerr, ok := err.(*mgo.LastError); ok {
+		timeout = lerr.Code == 50 || lerr.WTimeout
+	}
+	return
+}
+
+// isConnection checks if err was caused by a failure to reach the
+// database, or a connection lost.
+func isConnection(err error) (conn bool) {
+	var nerr net.Error
+	if conn = errors.As(err, &nerr) || err == io.EOF; !conn {
+		for i := 0; i < len(connectionMessages) && !conn; i++ {
+			conn = strings.Contains(err.Error(), connectionMessages[i])
+		}
+	}
+	return
+}
@@ -1,5 +1,7 @@
 module github.com/ddspog/mongo
 
+go 1.18
+
 require (
 	github.com/globalsign/mgo v0.0.0-20180424091348-efe0945164a7
 	gopkg.in/tomb.v2 v2.0.0-20161208151619-d5d1b5820637
@@ -3,8 +3,11 @@ package mongo
 import (
 	"errors"
 	"reflect"
+	"time"
 
+	"github.com/ddspog/mongo/internal/bsonutils"
 	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
 )
 
 var (
@@ -13,12 +16,26 @@ var (
 	// DocNotDefined it's an error received when the document received
 	// is nil.
 	DocNotDefined = errors.New("Document not defined")
+	// ErrPartialDocument it's an error received when trying to update
+	// with a document loaded with a projection.
+	ErrPartialDocument = errors.New("document partially loaded")
+	// ErrInvalidProjection it's an error received when a projection
+	// mixes fields to include with fields to exclude.
+	ErrInvalidProjection = errors.New("projection can't mix include and exclude fields")
+	// ErrNoSnapshot it's an error received when trying to update the
+	// changes of a document without an original to compare with.
+	ErrNoSnapshot = errors.New("no snapshot of document to compare")
+	// ErrDocumentNotBound it's an error received when mapping or
+	// initializing a Document not bound to the document embedding it.
+	ErrDocumentNotBound = errors.New("Document not bound to the document embedding it")
 )
 
 // Handle it's a type implementing the Handler interface, responsible
 // of taking documents and using them to manipulate collections.
 type Handle struct {
 	safely            bool
+	strict            bool
+	searchOnly        bool
 	socket            *DatabaseSocket
 	collection        *mgo.Collection
 	collectionName    string
@@ -26,6 +43,7 @@ type Handle struct {
 	DocumentV         Documenter
 	InternalErr       error
 	SearchMapV        M
+	snapshots         snapshotStore
 }
 
 // NewHandle creates a new Handle to be embedded onto handle for other
@@ -66,6 +84,7 @@ func (h *Handle) Safely() {
 // Clean resets handler values.
 func (h *Handle) Clean() {
 	h.SearchMapV = make(map[string]interface{})
+	h.snapshots = snapshotStore{}
 
 	if h.Document() != nil {
 		h.SetDocument(h.Document().New())
@@ -92,10 +111,55 @@ func (h *Handle) IsSearchEmpty() (result bool) {
 	return
 }
 
+// CountOptions enumerates different options altering result on
+// counts. Hint defines the index to use, and MaxTime the time limit
+// for the count to run on server.
+type CountOptions struct {
+	Skip    int
+	Limit   int
+	Hint    []string
+	MaxTime time.Duration
+}
+
 // Count returns the number of documents on collection connected to
-// Handle.
-func (h *Handle) Count() (n int, err error) {
+// Handle, matching the document data. Accepts options to alter result.
+func (h *Handle) Count(opts ...CountOptions) (n int, err error) {
 	defer h.ifSafelyClose()
+	defer wrapErr(&err)
+
+	if err = h.InternalErr; err == nil {
+		var mapped M
+		if mapped, err = h.mapped(); err == nil {
+			qry := h.collection.Find(mapped)
+
+			if len(opts) == 1 {
+				if opts[0].Skip > 0 {
+					qry = qry.Skip(opts[0].Skip)
+				}
+				if opts[0].Limit > 0 {
+					qry = qry.Limit(opts[0].Limit)
+				}
+				if len(opts[0].Hint) > 0 {
+					qry = qry.Hint(opts[0].Hint...)
+				}
+				if opts[0].MaxTime > 0 {
+					qry = qry.SetMaxTime(opts[0].MaxTime)
+				}
+			}
+
+			n, err = qry.Count()
+		}
+	}
+
+	return
+}
+
+// EstimatedCount returns the number of documents on collection
+// connected to Handle, using the collection metadata. It's faster
+// than Count, but ignores the document data and may be inaccurate.
+func (h *Handle) EstimatedCount() (n int, err error) {
+	defer h.ifSafelyClose()
+	defer wrapErr(&err)
 
 	if err = h.InternalErr; err == nil {
 		n, err = h.collection.Count()
@@ -104,19 +168,59 @@ func (h *Handle) Count() (n int, err error) {
 	return
 }
 
+// Distinct search for the distinct values of field on documents
+// matching the doc data on collection connected to Handle, and decodes
+// them onto result, which must be a pointer to a slice. Accepts a
+// collation, to compare values like case-insensitively.
+func (h *Handle) Distinct(field string, result interface{}, collation ...*mgo.Collation) (err error) {
+	defer h.ifSafelyClose()
+	defer wrapErr(&err)
+
+	if err = h.InternalErr; err == nil {
+		var mapped M
+		if mapped, err = h.mapped(); err == nil {
+			cmd := bson.D{
+				{Name: "distinct", Value: h.collection.Name},
+				{Name: "key", Value: field},
+				{Name: "query", Value: mapped},
+			}
+			if len(collation) == 1 && collation[0] != nil {
+				cmd = append(cmd, bson.DocElem{Name: "collation", Value: collation[0]})
+			}
+
+			var doc struct {
+				Values bson.Raw
+			}
+			if err = h.collection.Database.Run(cmd, &doc); err == nil {
+				err = doc.Values.Unmarshal(result)
+			}
+		}
+	}
+
+	return
+}
+
 // Find search for a document matching the doc data on collection
-// connected to Handle.
-func (h *Handle) Find() (out Documenter, err error) {
+// connected to Handle. Accepts options to alter result. A snapshot of
+// the document found is kept, to be used by UpdateChanges.
+func (h *Handle) Find(opts ...QueryOptions) (out Documenter, err error) {
 	defer h.ifSafelyClose()
+	defer wrapErr(&err)
 
 	if err = h.InternalErr; err == nil {
 		out = h.Document().New()
 
 		var mapped M
 		if mapped, err = h.mapped(); err == nil {
-			var result interface{}
-			if err = h.collection.Find(mapped).One(&result); err == nil {
-				err = out.Init(result.(M))
+			var qry *mgo.Query
+			if qry, err = h.query(mapped, opts...); err == nil {
+				var result bson.Raw
+				if err = qry.One(&result); err == nil {
+					if err = decode(result, out); err == nil {
+						setProjection(out, projection(opts...))
+						err = h.snapshot(out)
+					}
+				}
 			}
 		}
 	}
@@ -124,32 +228,53 @@ func (h *Handle) Find() (out Documenter, err error) {
 }
 
 // QueryOptions enumerates different options altering result on queries.
+// Include and Exclude define a projection, restricting the fields
+// loaded onto documents found. MaxTime defines the time limit for the
+// query to run on server.
 type QueryOptions struct {
-	Sort []string
+	Sort      []string
+	Skip      int
+	Limit     int
+	BatchSize int
+	Include   []string
+	Exclude   []string
+	MaxTime   time.Duration
 }
 
 // FindAll search for all documents matching the document data on
 // collection connected to Handle. Accepts options to alter result.
 func (h *Handle) FindAll(opts ...QueryOptions) (out []Documenter, err error) {
 	defer h.ifSafelyClose()
+	defer wrapErr(&err)
 
 	if err = h.InternalErr; err == nil {
 		var mapped M
 		if mapped, err = h.mapped(); err == nil {
-			var result []interface{}
-			qry := h.collection.Find(mapped)
-
-			if len(opts) == 1 {
-				if opts[0].Sort != nil {
-					qry = qry.Sort(opts[0].Sort...)
-				}
+			var qry *mgo.Query
+			if qry, err = h.query(mapped, opts...); err == nil {
+				out, err = h.all(qry, projection(opts...))
 			}
+		}
+	}
+
+	return
+}
 
-			if err = qry.All(&result); err == nil {
-				out = make([]Documenter, len(result))
-				for i := 0; i < len(result) && err == nil; i++ {
-					out[i] = h.Document().New()
-					err = out[i].Init(result[i].(M))
+// FindPage search for a page of documents matching the document data
+// on collection connected to Handle. The page is delimited by the Skip
+// and Limit options, and it also returns the total number of documents
+// matching the search, ignoring these options.
+func (h *Handle) FindPage(opts ...QueryOptions) (out []Documenter, total int, err error) {
+	defer h.ifSafelyClose()
+	defer wrapErr(&err)
+
+	if err = h.InternalErr; err == nil {
+		var mapped M
+		if mapped, err = h.mapped(); err == nil {
+			var qry *mgo.Query
+			if qry, err = h.query(mapped, opts...); err == nil {
+				if total, err = h.collection.Find(mapped).Count(); err == nil {
+					out, err = h.all(qry, projection(opts...))
 				}
 			}
 		}
@@ -162,6 +287,7 @@ func (h *Handle) FindAll(opts ...QueryOptions) (out []Documenter, err error) {
 // document data.
 func (h *Handle) Insert() (err error) {
 	defer h.ifSafelyClose()
+	defer wrapErr(&err)
 
 	if err = h.InternalErr; err == nil {
 		if h.Document().ID() == "" {
@@ -188,6 +314,7 @@ func (h *Handle) Insert() (err error) {
 // id received.
 func (h *Handle) Remove(id ObjectId) (err error) {
 	defer h.ifSafelyClose()
+	defer wrapErr(&err)
 
 	if err = h.InternalErr; err == nil {
 		if id == "" {
@@ -204,6 +331,7 @@ func (h *Handle) Remove(id ObjectId) (err error) {
 // matching the document data.
 func (h *Handle) RemoveAll() (info *mgo.ChangeInfo, err error) {
 	defer h.ifSafelyClose()
+	defer wrapErr(&err)
 
 	if err = h.InternalErr; err == nil {
 		var mapped M
@@ -219,10 +347,13 @@ func (h *Handle) RemoveAll() (info *mgo.ChangeInfo, err error) {
 // matching id received, updating with the information on doc.
 func (h *Handle) Update(id ObjectId) (err error) {
 	defer h.ifSafelyClose()
+	defer wrapErr(&err)
 
 	if err = h.InternalErr; err == nil {
 		if id == "" {
 			err = ErrIDNotDefined
+		} else if IsPartial(h.Document()) {
+			err = ErrPartialDocument
 		} else {
 			h.Document().CalculateUpdatedOn()
 
@@ -243,12 +374,14 @@ func (h *Handle) Update(id ObjectId) (err error) {
 	return
 }
 
-// SetDocument sets product on Handle.
+// SetDocument sets product on Handle, binding it to the Document it
+// embeds, if any.
 func (h *Handle) SetDocument(d Documenter) {
 	if reflect.ValueOf(d).IsNil() {
 		h.InternalErr = DocNotDefined
 	} else {
-		h.InternalErr = d.Validate()
+		bind(d)
+		h.InternalErr = validationErr(d.Validate())
 	}
 
 	h.DocumentV = d
@@ -280,19 +413,143 @@ func (h *Handle) ensureIndexes() {
 	for i := 0; i < len(h.collectionIndexes) && h.InternalErr == nil; i++ {
 		h.InternalErr = h.collection.EnsureIndex(h.collectionIndexes[i])
 	}
+
+	wrapErr(&h.InternalErr)
 }
 
 // mapped returns SearchMap if it isn't empty, or the Document mapped.
+// Handles created by a Repository use only SearchMap. On strict
+// Handles, the fields of SearchMap are verified.
 func (h *Handle) mapped() (m M, err error) {
-	if h.IsSearchEmpty() {
+	if h.IsSearchEmpty() && !h.searchOnly {
 		m, err = h.Document().Map()
-	} else {
+	} else if err = h.checkFilter(h.SearchMap()); err == nil {
 		m = h.SearchMap()
 	}
 
 	return
 }
 
+// query creates a query on collection connected to Handle, searching
+// for the m received and applying the options received.
+func (h *Handle) query(m M, opts ...QueryOptions) (qry *mgo.Query, err error) {
+	qry = h.collection.Find(m)
+
+	if len(opts) == 1 {
+		if opts[0].Sort != nil {
+			qry = qry.Sort(opts[0].Sort...)
+		}
+		if opts[0].Skip > 0 {
+			qry = qry.Skip(opts[0].Skip)
+		}
+		if opts[0].Limit > 0 {
+			qry = qry.Limit(opts[0].Limit)
+		}
+		if opts[0].BatchSize > 0 {
+			qry = qry.Batch(opts[0].BatchSize)
+		}
+		if opts[0].MaxTime > 0 {
+			qry = qry.SetMaxTime(opts[0].MaxTime)
+		}
+		if err = opts[0].validateProjection(); err == nil {
+			if proj := projection(opts...); proj != nil {
+				qry = qry.Select(proj)
+			}
+		}
+		if err == nil {
+			err = h.checkSort(opts[0].Sort)
+		}
+		if err == nil {
+			err = h.checkPaths("projection", opts[0].Include...)
+		}
+		if err == nil {
+			err = h.checkPaths("projection", opts[0].Exclude...)
+		}
+	}
+
+	return
+}
+
+// all runs the query received, returning every document found as new
+// Documenter of the same type of the Handle document. The projection
+// received is stored on each document.
+func (h *Handle) all(qry *mgo.Query, proj M) (out []Documenter, err error) {
+	var result []bson.Raw
+	if err = qry.All(&result); err == nil {
+		out, err = h.documents(result, proj)
+	}
+
+	return
+}
+
+// documents decodes each result of a query onto a new Documenter of
+// the same type of the Handle document, storing projection received.
+func (h *Handle) documents(result []bson.Raw, proj M) (out []Documenter, err error) {
+	out = make([]Documenter, len(result))
+	for i := 0; i < len(result) && err == nil; i++ {
+		out[i] = h.Document().New()
+		if err = decode(result[i], out[i]); err == nil {
+			setProjection(out[i], proj)
+		}
+	}
+
+	return
+}
+
+// decode decodes the raw document received straight onto document d,
+// without translating it to a M for Init, binding d to the Document it
+// embeds.
+func decode(raw bson.Raw, d Documenter) (err error) {
+	if err = bsonutils.UnmarshalWith(documentOptions, raw.Data, d); err == nil {
+		bind(d)
+	}
+	return
+}
+
+// validateProjection checks if the projection defined on options
+// doesn't mix fields to include and exclude. The only exception is
+// the _id field, which can be excluded on any projection.
+func (o QueryOptions) validateProjection() (err error) {
+	if len(o.Include) > 0 {
+		for i := 0; i < len(o.Exclude) && err == nil; i++ {
+			if o.Exclude[i] != "_id" {
+				err = ErrInvalidProjection
+			}
+		}
+	}
+
+	return
+}
+
+// projection returns the projection defined on options, or nil when
+// no fields are selected.
+func projection(opts ...QueryOptions) (proj M) {
+	if len(opts) == 1 && len(opts[0].Include)+len(opts[0].Exclude) > 0 {
+		proj = M{}
+		for _, f := range opts[0].Include {
+			proj[f] = 1
+		}
+		for _, f := range opts[0].Exclude {
+			proj[f] = 0
+		}
+	}
+
+	return
+}
+
+// snapshot stores the mapped version of document received, to be
+// compared later when updating its changes.
+func (h *Handle) snapshot(d Documenter) (err error) {
+	if d.ID() != "" {
+		var mapped M
+		if mapped, err = fullMap(d); err == nil {
+			h.snapshots.put(d.ID(), mapped)
+		}
+	}
+
+	return
+}
+
 // ifSafelyClose checks if safely was activated to close socket.
 func (h *Handle) ifSafelyClose() {
 	if h.safely {
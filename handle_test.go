@@ -3,11 +3,15 @@
 package mongo
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"reflect"
 	"testing"
 	"time"
 
 	"github.com/ddspog/bdd"
+	"github.com/globalsign/mgo"
 )
 
 // TestMain setup the testable mongo connecter to run a temp database.
@@ -67,6 +71,48 @@ func Test_Count_documents_with_Handle(t *testing.T) {
 		s(len(fixtures)),
 	))
 
+	given(t, "a ProductHandle p searching for %[1]v", func(when bdd.When, args ...interface{}) {
+		when("p.Count() is called", func(it bdd.It) {
+			n, err := newProductHandle().Safely().SearchFor(args[0].(M)).Count()
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.Nil(err)
+			})
+			it("should return %[2]v", func(assert bdd.Assert) {
+				assert.Equal(args[1].(int), n)
+			})
+		})
+
+		when("p.Count() is called with Skip 1 and Limit 1", func(it bdd.It) {
+			n, err := newProductHandle().Safely().SearchFor(args[0].(M)).Count(CountOptions{
+				Skip:  1,
+				Limit: 1,
+			})
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.Nil(err)
+			})
+			it("should return %[3]v", func(assert bdd.Assert) {
+				assert.Equal(args[2].(int), n)
+			})
+		})
+
+		when("p.EstimatedCount() is called", func(it bdd.It) {
+			n, err := newProductHandle().Safely().SearchFor(args[0].(M)).EstimatedCount()
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.Nil(err)
+			})
+			it("should return the number of documents on collection", func(assert bdd.Assert) {
+				assert.Equal(len(fixtures), n)
+			})
+		})
+	}, like(
+		s(M{"_id": fixture(1).ID()}, 1, 0),
+		s(M{"_id": M{"$ne": fixture(1).ID()}}, 2, 1),
+		s(M{"_id": ObjectIdHex(idE)}, 0, 0),
+	))
+
 	given(t, "a ProductHandle with a nil document", func(when bdd.When) {
 		when("p.Count() is called", func(it bdd.It) {
 			n, err := newProductHandle().SetDocument(nil).Safely().Count()
@@ -83,6 +129,45 @@ func Test_Count_documents_with_Handle(t *testing.T) {
 	})
 }
 
+// Feature Find distinct values with Handle
+// - As a developer,
+// - I want to find the distinct values of a field using Handle,
+// - So that I can list the options available on a collection.
+func Test_Find_distinct_values_with_Handle(t *testing.T) {
+	given, like, s := bdd.Sentences().All()
+
+	given(t, "a ProductHandle p searching for %[1]v", func(when bdd.When, args ...interface{}) {
+		when("p.Distinct('_id', &ids) is called", func(it bdd.It) {
+			var ids []ObjectId
+			err := newProductHandle().Safely().SearchFor(args[0].(M)).Distinct("_id", &ids)
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.Nil(err)
+			})
+			it("should find %[2]v values", func(assert bdd.Assert) {
+				assert.Len(ids, args[1].(int))
+			})
+		})
+
+		when("p.Distinct('_id', &ids, collation) is called", func(it bdd.It) {
+			var ids []ObjectId
+			collation := &mgo.Collation{Locale: "en", Strength: 2}
+			err := newProductHandle().Safely().SearchFor(args[0].(M)).Distinct("_id", &ids, collation)
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.Nil(err)
+			})
+			it("should find %[2]v values", func(assert bdd.Assert) {
+				assert.Len(ids, args[1].(int))
+			})
+		})
+	}, like(
+		s(M{"_id": M{"$exists": true}}, len(fixtures)),
+		s(M{"_id": fixture(1).ID()}, 1),
+		s(M{"_id": ObjectIdHex(idE)}, 0),
+	))
+}
+
 // Feature Clean documents with Handle
 // - As a developer,
 // - I want to Clean documents on Handle,
@@ -411,3 +496,1179 @@ func Test_Update_documents_with_Handle(t *testing.T) {
 	))
 
 }
+
+// Feature Find a page of documents with Handle
+// - As a developer,
+// - I want to Find a page of documents using Handle,
+// - So that I can paginate through data without loading all of it.
+func Test_Find_a_page_of_documents_with_Handle(t *testing.T) {
+	defer cleanChanges()
+	given, like, s := bdd.Sentences().All()
+
+	given(t, "a linked ProductHandle p with products collection with documents "+colFixtures, func(when bdd.When, args ...interface{}) {
+		p := newProductHandle()
+
+		when("da, total, err := p.FindPage() is called with Skip %[1]v and Limit %[2]v", func(it bdd.It) {
+			da, total, err := p.Safely().FindPage(QueryOptions{
+				Sort:  []string{"_id"},
+				Skip:  args[0].(int),
+				Limit: args[1].(int),
+			})
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.Nil(err)
+			})
+			it("should return total equal to %[3]v", func(assert bdd.Assert) {
+				assert.Equal(args[2].(int), total)
+			})
+			it("should return %[4]v documents", func(assert bdd.Assert) {
+				assert.Equal(len(args[3].([]string)), len(da))
+			})
+
+			for i := range da {
+				aID := args[3].([]string)[i]
+				it(fmt.Sprintf("da[%d].ID().Hex() should return %s", i, aID), func(assert bdd.Assert) {
+					assert.Equal(aID, da[i].ID().Hex())
+				})
+			}
+		})
+	}, like(
+		s(0, 2, 3, []string{fixture(1).ID().Hex(), fixture(2).ID().Hex()}),
+		s(2, 2, 3, []string{fixture(3).ID().Hex()}),
+		s(1, 0, 3, []string{fixture(2).ID().Hex(), fixture(3).ID().Hex()}),
+		s(3, 1, 3, []string{}),
+	))
+}
+
+// Feature Find documents after a token with Handle
+// - As a developer,
+// - I want to Find documents after a continuation token using Handle,
+// - So that I can paginate large collections with constant cost.
+func Test_Find_documents_after_a_token_with_Handle(t *testing.T) {
+	defer cleanChanges()
+	given, like, s := bdd.Sentences().All()
+
+	given(t, "a linked ProductHandle p with products collection with documents "+colFixtures, func(when bdd.When) {
+		opts := QueryOptions{
+			Sort:  []string{"-created_on"},
+			Limit: 2,
+		}
+
+		first, next, errFirst := newProductHandle().Safely().FindAfter("", opts)
+
+		when("first, next, err := p.FindAfter('') is called with Limit 2", func(it bdd.It) {
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.Nil(errFirst)
+			})
+			it("should return 2 documents", func(assert bdd.Assert) {
+				assert.Equal(2, len(first))
+			})
+			it("should return a token for next page", func(assert bdd.Assert) {
+				assert.NotEqual("", next)
+			})
+		})
+
+		second, last, errSecond := newProductHandle().Safely().FindAfter(next, opts)
+
+		when("second, last, err := p.FindAfter(next) is called with Limit 2", func(it bdd.It) {
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.Nil(errSecond)
+			})
+			it("should return 1 document", func(assert bdd.Assert) {
+				assert.Equal(1, len(second))
+			})
+			it("should return an empty token", func(assert bdd.Assert) {
+				assert.Equal("", last)
+			})
+			it("should not repeat documents from first page", func(assert bdd.Assert) {
+				for i := range first {
+					for j := range second {
+						assert.NotEqual(first[i].ID(), second[j].ID())
+					}
+				}
+			})
+		})
+
+		_, _, errInvalid := newProductHandle().Safely().FindAfter(next, QueryOptions{
+			Sort:  []string{"_id"},
+			Limit: 2,
+		})
+
+		when("p.FindAfter(next) is called with a different sort", func(it bdd.It) {
+			it("should return ErrInvalidToken", func(assert bdd.Assert) {
+				assert.Equal(ErrInvalidToken, errInvalid)
+			})
+		})
+
+		_, _, errMissing := newProductHandle().Safely().FindAfter("", QueryOptions{
+			Sort:    []string{"-created_on"},
+			Limit:   1,
+			Include: []string{"_id"},
+		})
+
+		when("p.FindAfter('') is called with a projection without the sort fields", func(it bdd.It) {
+			it("should return ErrMissingSortKey", func(assert bdd.Assert) {
+				assert.Equal(ErrMissingSortKey, errMissing)
+			})
+		})
+	})
+
+	given(t, "the last document of a page %[1]v, sorted by 'name'", func(when bdd.When, args ...interface{}) {
+		when("encodeToken(doc, sort) is called", func(it bdd.It) {
+			_, err := encodeToken(args[0].(M), []string{"name", "_id"})
+
+			it("should return ErrMissingSortKey", func(assert bdd.Assert) {
+				assert.Equal(ErrMissingSortKey, err)
+			})
+		})
+	}, like(
+		s(M{"_id": fixture(1).ID()}),
+		s(M{"_id": fixture(1).ID(), "name": nil}),
+	))
+}
+
+// Feature Iterate through documents with Handle
+// - As a developer,
+// - I want to iterate through documents using Handle,
+// - So that I can process large collections one document at a time.
+func Test_Iterate_through_documents_with_Handle(t *testing.T) {
+	defer cleanChanges()
+	given, like, s := bdd.Sentences().All()
+
+	given(t, "a linked ProductHandle p with products collection with documents "+colFixtures, func(when bdd.When, args ...interface{}) {
+		when("it := p.Iter() is called and consumed", func(it bdd.It) {
+			iter := newProductHandle().Safely().Iter(QueryOptions{
+				Sort: []string{"_id"},
+			})
+
+			var ids []string
+			for doc, ok := iter.Next(); ok; doc, ok = iter.Next() {
+				ids = append(ids, doc.ID().Hex())
+			}
+			err := iter.Close()
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.Nil(err)
+			})
+			it("should iterate through all documents in order", func(assert bdd.Assert) {
+				assert.Equal([]string{fixture(1).ID().Hex(), fixture(2).ID().Hex(), fixture(3).ID().Hex()}, ids)
+			})
+		})
+
+		when("p.Each(f) is called with f stopping after %[1]v documents", func(it bdd.It) {
+			n := 0
+			err := newProductHandle().Safely().Each(func(doc Documenter) (err error) {
+				if n++; n == args[0].(int) {
+					err = ErrStopIteration
+				}
+				return
+			})
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.Nil(err)
+			})
+			it("should have consumed %[1]v documents", func(assert bdd.Assert) {
+				assert.Equal(args[0].(int), n)
+			})
+		})
+
+		when("p.Each(f) is called with f stopping after %[1]v documents, counting the documents decoded", func(it bdd.It) {
+			decoded := 0
+			n := 0
+			p := NewHandle("products", &countingProduct{decoded: &decoded})
+			p.Safely()
+
+			err := p.Each(func(doc Documenter) (err error) {
+				if n++; n == args[0].(int) {
+					err = ErrStopIteration
+				}
+				return
+			})
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.Nil(err)
+			})
+			it("should have decoded only %[1]v documents", func(assert bdd.Assert) {
+				assert.Equal(args[0].(int), decoded)
+			})
+		})
+	}, like(
+		s(1), s(2), s(3),
+	))
+}
+
+// Feature Find documents with projection with Handle
+// - As a developer,
+// - I want to Find documents loading only some fields using Handle,
+// - So that I can avoid transferring data I don't need.
+func Test_Find_documents_with_projection_with_Handle(t *testing.T) {
+	defer cleanChanges()
+	given, like, s := bdd.Sentences().All()
+
+	given(t, "a linked ProductHandle p with products collection with documents "+colFixtures, func(when bdd.When, args ...interface{}) {
+		p := newProductHandle()
+
+		when("d, err := p.Find() is called with Document id '%[1]v' and Include ['_id']", func(it bdd.It) {
+			p.Document().IDV = ObjectIdHex(args[0].(string))
+			d, err := p.Find(QueryOptions{
+				Include: []string{"_id"},
+			})
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.Nil(err)
+			})
+			it("d.ID().Hex() should return %[1]v", func(assert bdd.Assert) {
+				assert.Equal(args[0].(string), d.ID().Hex())
+			})
+			it("d.CreatedOn() should return 0", func(assert bdd.Assert) {
+				assert.Equal(int64(0), d.CreatedOn())
+			})
+			it("IsPartial(d) should return true", func(assert bdd.Assert) {
+				assert.True(IsPartial(d))
+			})
+
+			errUpdate := p.SetDocument(d).Update(d.ID())
+
+			it("p.SetDocument(d).Update(d.ID()) should return ErrPartialDocument", func(assert bdd.Assert) {
+				assert.Equal(ErrPartialDocument, errUpdate)
+			})
+
+			_, _, errModify := p.FindAndModify(ModifyOptions{Update: d})
+			_, errBulk := p.BulkWrite(true, BulkUpdate(M{"_id": d.ID()}, d))
+
+			it("p.FindAndModify and p.BulkWrite updating with d should return ErrPartialDocument", func(assert bdd.Assert) {
+				assert.Equal(ErrPartialDocument, errModify)
+				assert.Equal(ErrPartialDocument, errBulk)
+			})
+		})
+
+		p.Clean()
+
+		when("da, err := p.FindAll() is called with Include ['_id'] and Exclude ['created_on']", func(it bdd.It) {
+			_, err := p.Safely().FindAll(QueryOptions{
+				Include: []string{"_id"},
+				Exclude: []string{"created_on"},
+			})
+
+			it("should return ErrInvalidProjection", func(assert bdd.Assert) {
+				assert.Equal(ErrInvalidProjection, err)
+			})
+		})
+	}, like(
+		s(fixture(1).ID().Hex()), s(fixture(2).ID().Hex()), s(fixture(3).ID().Hex()),
+	))
+}
+
+// Feature Update changes of documents with Handle
+// - As a developer,
+// - I want to Update only the changed fields of documents using Handle,
+// - So that I don't overwrite fields changed by other writers.
+func Test_Update_changes_of_documents_with_Handle(t *testing.T) {
+	defer cleanChanges()
+	given, like, s := bdd.Sentences().All()
+
+	given(t, "a linked ProductHandle p with products collection with documents "+colFixtures, func(when bdd.When, args ...interface{}) {
+		p := newProductHandle()
+		defer p.Close()
+
+		when("p.UpdateChanges('%[1]v') is called without a snapshot", func(it bdd.It) {
+			err := p.UpdateChanges(args[0].(ObjectId))
+
+			it("should return ErrNoSnapshot", func(assert bdd.Assert) {
+				assert.Equal(ErrNoSnapshot, err)
+			})
+		})
+
+		when("p.UpdateChanges('%[1]v') is called after p.Find()", func(it bdd.It) {
+			now = func() (t time.Time) {
+				t = args[1].(time.Time)
+				return
+			}
+			defer resetUtils()
+
+			d, errFind := p.SearchFor(M{"_id": args[0].(ObjectId)}).Find()
+			err := p.SetDocument(d).UpdateChanges(d.ID())
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.Nil(errFind)
+				assert.Nil(err)
+			})
+			it("should have p.Document().UpdatedOn() return %[2]v", func(assert bdd.Assert) {
+				assert.Equal(expectedNowInMilli(args[1].(time.Time)), p.Document().UpdatedOn())
+			})
+		})
+	}, like(
+		s(fixture(1).ID(), timeFmt("14-03-1998 12:15:06")),
+		s(fixture(2).ID(), timeFmt("22-10-1974 03:11:02")),
+		s(fixture(3).ID(), timeFmt("07-12-2007 02:48:59")),
+	))
+
+	given(t, "an original document %[1]v and a changed document %[2]v", func(when bdd.When, args ...interface{}) {
+		when("diff(original, changed) is called", func(it bdd.It) {
+			set, unset := M{}, M{}
+			diff("", args[0].(M), args[1].(M), set, unset)
+
+			it("should set %[3]v", func(assert bdd.Assert) {
+				assert.Equal(args[2].(M), set)
+			})
+			it("should unset %[4]v", func(assert bdd.Assert) {
+				assert.Equal(args[3].(M), unset)
+			})
+		})
+	}, like(
+		s(M{"a": 1, "b": 2}, M{"a": 1, "b": 3}, M{"b": 3}, M{}),
+		s(M{"a": 1, "b": 2}, M{"a": 1}, M{}, M{"b": ""}),
+		s(M{"a": M{"b": 1, "c": 2}}, M{"a": M{"b": 1, "c": 3, "d": 4}}, M{"a.c": 3, "a.d": 4}, M{}),
+		s(M{"_id": 1, "updated_on": 2}, M{"_id": 1, "updated_on": 3, "x": []interface{}{1}}, M{"x": []interface{}{1}}, M{}),
+	))
+
+	given(t, "an original item with name 'bread' and a changed item with an empty name", func(when bdd.When) {
+		original, changed := &item{NameV: "bread"}, &item{}
+
+		when("diff(fullMap(original), fullMap(changed)) is called", func(it bdd.It) {
+			old, errOld := fullMap(original)
+			cur, errCur := fullMap(changed)
+
+			set, unset := M{}, M{}
+			diff("", old, cur, set, unset)
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.NoError(errOld)
+				assert.NoError(errCur)
+			})
+			it("should set the empty name, instead of unsetting it", func(assert bdd.Assert) {
+				assert.Equal(M{"name": ""}, set)
+				assert.Equal(M{}, unset)
+			})
+		})
+	})
+
+	given(t, "a snapshotStore with more snapshots put than maxSnapshots", func(when bdd.When) {
+		var store snapshotStore
+
+		ids := make([]ObjectId, maxSnapshots+1)
+		for i := range ids {
+			ids[i] = NewID()
+			store.put(ids[i], M{})
+		}
+
+		when("the snapshots are read", func(it bdd.It) {
+			_, firstKept := store.get(ids[0])
+			_, lastKept := store.get(ids[maxSnapshots])
+
+			it("should discard the oldest snapshot", func(assert bdd.Assert) {
+				assert.False(firstKept)
+				assert.True(lastKept)
+				assert.Len(store.mapped, maxSnapshots)
+			})
+		})
+	})
+}
+
+// Feature Update documents with operators with Handle
+// - As a developer,
+// - I want to Update documents with update operators using Handle,
+// - So that I can modify documents atomically.
+func Test_Update_documents_with_operators_with_Handle(t *testing.T) {
+	defer cleanChanges()
+	given, like, s := bdd.Sentences().All()
+
+	given(t, "a Ops o with operations on paths %[1]v and %[2]v", func(when bdd.When, args ...interface{}) {
+		when("o.Map() is called", func(it bdd.It) {
+			_, err := NewOps().Inc(args[0].(string), 1).Set(args[1].(string), 2).Map()
+
+			if args[2].(bool) {
+				it("should return ErrConflictingPaths", func(assert bdd.Assert) {
+					assert.True(errors.Is(err, ErrConflictingPaths))
+				})
+			} else {
+				it("should return no errors", func(assert bdd.Assert) {
+					assert.Nil(err)
+				})
+			}
+		})
+	}, like(
+		s("a", "b", false), s("a", "a", true), s("a", "a.b", true),
+		s("a.b", "a", true), s("a.b", "a.c", false), s("ab", "a", false),
+	))
+
+	given(t, "a zero value Ops o", func(when bdd.When) {
+		var o Ops
+
+		when("o.Set('a', 1).Map() is called", func(it bdd.It) {
+			out, err := o.Set("a", 1).Map()
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.Nil(err)
+			})
+			it("should return the $set operator", func(assert bdd.Assert) {
+				assert.Equal(M{"$set": M{"a": 1}}, out)
+			})
+		})
+	})
+
+	given(t, "a linked ProductHandle p with products collection with documents "+colFixtures, func(when bdd.When, args ...interface{}) {
+		when("p.UpdateWith('%[1]v', NewOps().Inc('views', 1)) is called", func(it bdd.It) {
+			now = func() (t time.Time) {
+				t = args[1].(time.Time)
+				return
+			}
+			defer resetUtils()
+
+			err := newProductHandle().Safely().UpdateWith(args[0].(ObjectId), NewOps().Inc("views", 1))
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.Nil(err)
+			})
+
+			d, errFind := newProductHandle().Safely().SearchFor(M{"_id": args[0].(ObjectId)}).Find()
+
+			it("should have updated_on on database equal to %[2]v", func(assert bdd.Assert) {
+				assert.Nil(errFind)
+				assert.Equal(expectedNowInMilli(args[1].(time.Time)), d.UpdatedOn())
+			})
+		})
+
+		when("p.UpdateAllWith(NewOps().Inc('views', 1)) is called", func(it bdd.It) {
+			info, err := newProductHandle().Safely().UpdateAllWith(NewOps().Inc("views", 1))
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.Nil(err)
+			})
+			it(fmt.Sprintf("should have updated %d documents", len(fixtures)), func(assert bdd.Assert) {
+				assert.Equal(len(fixtures), info.Updated)
+			})
+		})
+	}, like(
+		s(fixture(1).ID(), timeFmt("14-03-1998 12:15:06")),
+		s(fixture(2).ID(), timeFmt("22-10-1974 03:11:02")),
+	))
+}
+
+// Feature Upsert documents with Handle
+// - As a developer,
+// - I want to Upsert documents using Handle,
+// - So that I can insert or update data without races.
+func Test_Upsert_documents_with_Handle(t *testing.T) {
+	defer cleanChanges()
+	given, like, s := bdd.Sentences().All()
+
+	given(t, "a linked ProductHandle p with ID '%[1]v'", func(when bdd.When, args ...interface{}) {
+		p := newProductHandle()
+
+		if args[0].(string) != "" {
+			p.Document().IDV = ObjectIdHex(args[0].(string))
+		}
+
+		when("created, id, err := p.Upsert() is called", func(it bdd.It) {
+			created, id, err := p.Safely().Upsert()
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.Nil(err)
+			})
+			it("should return created equal to %[2]v", func(assert bdd.Assert) {
+				assert.Equal(args[1].(bool), created)
+			})
+			it("should return id equal to p.Document().ID()", func(assert bdd.Assert) {
+				assert.NotEqual(ObjectId(""), id)
+				assert.Equal(p.Document().ID(), id)
+			})
+
+			if !created {
+				it("should keep p.Document().CreatedOn() equal to %[3]v", func(assert bdd.Assert) {
+					assert.Equal(args[2].(int64), p.Document().CreatedOn())
+				})
+			}
+		})
+	}, like(
+		s("", true),
+		s(idE, true),
+		s(fixture(1).ID().Hex(), false, fixture(1).CreatedOn()),
+	))
+}
+
+// Feature Find and modify documents with Handle
+// - As a developer,
+// - I want to modify and return documents atomically using Handle,
+// - So that I can implement counters and job claiming.
+func Test_Find_and_modify_documents_with_Handle(t *testing.T) {
+	defer cleanChanges()
+	given, like, s := bdd.Sentences().All()
+
+	given(t, "a linked ProductHandle p searching for '_id' equal '%[1]v'", func(when bdd.When, args ...interface{}) {
+		when("d, info, err := p.FindAndModify() is called with ReturnNew %[3]v", func(it bdd.It) {
+			now = func() (t time.Time) {
+				t = args[1].(time.Time)
+				return
+			}
+			defer resetUtils()
+
+			d, info, err := newProductHandle().Safely().SearchFor(M{
+				"_id": args[0].(ObjectId),
+			}).FindAndModify(ModifyOptions{
+				Update:    NewOps().Inc("views", 1),
+				ReturnNew: args[2].(bool),
+			})
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.Nil(err)
+			})
+			it("should have updated 1 document", func(assert bdd.Assert) {
+				assert.Equal(1, info.Updated)
+			})
+			it("d.ID() should return %[1]v", func(assert bdd.Assert) {
+				assert.Equal(args[0].(ObjectId), d.ID())
+			})
+			it("d.UpdatedOn() should return %[4]v", func(assert bdd.Assert) {
+				assert.Equal(args[3].(int64), d.UpdatedOn())
+			})
+		})
+	}, like(
+		s(fixture(1).ID(), timeFmt("14-03-1998 12:15:06"), true, expectedNowInMilli(timeFmt("14-03-1998 12:15:06"))),
+		s(fixture(2).ID(), timeFmt("22-10-1974 03:11:02"), false, int64(0)),
+	))
+
+	given(t, "a linked ProductHandle p searching for '_id' equal '%[1]v'", func(when bdd.When, args ...interface{}) {
+		when("_, _, err := p.FindAndModify() is called", func(it bdd.It) {
+			_, _, err := newProductHandle().Safely().SearchFor(M{
+				"_id": ObjectIdHex(args[0].(string)),
+			}).FindAndModify(ModifyOptions{
+				Update: M{"$inc": M{"views": 1}},
+			})
+
+			it("should return an error", func(assert bdd.Assert) {
+				assert.Error(err)
+			})
+		})
+	}, like(
+		s(idE),
+	))
+
+	given(t, "a linked ProductHandle p searching for '_id' equal the ID of a document stored", func(when bdd.When) {
+		when("_, _, err := p.FindAndModify() is called with Update %[1]v", func(it bdd.It, args ...interface{}) {
+			_, _, err := newProductHandle().Safely().SearchFor(M{
+				"_id": fixture(1).ID(),
+			}).FindAndModify(ModifyOptions{
+				Update: args[0],
+			})
+			d, errFind := newProductHandle().Safely().SearchFor(M{"_id": fixture(1).ID()}).Find()
+
+			it("should return ErrUpdateNotDefined", func(assert bdd.Assert) {
+				assert.Equal(ErrUpdateNotDefined, err)
+			})
+			it("should leave the document stored untouched", func(assert bdd.Assert) {
+				assert.Nil(errFind)
+				assert.Equal(fixture(1).CreatedOn(), d.CreatedOn())
+			})
+		}, like(
+			s(M{}), s(M(nil)), s(M{"_id": fixture(1).ID()}),
+		))
+
+		when("_, _, err := p.FindAndModify() is called with an invalid document as Update", func(it bdd.It) {
+			_, _, err := newProductHandle().Safely().SearchFor(M{
+				"_id": fixture(1).ID(),
+			}).FindAndModify(ModifyOptions{
+				Update: &invalidProduct{},
+			})
+
+			it("should return a ValidationError", func(assert bdd.Assert) {
+				var verr *ValidationError
+				assert.True(errors.As(err, &verr))
+			})
+		})
+	})
+
+	given(t, "a linked ProductHandle p searching for '_id' equal a new ID", func(when bdd.When) {
+		when("d, info, err := p.FindAndModify() is called upserting a new product", func(it bdd.It) {
+			now = func() (t time.Time) {
+				t = timeFmt("14-03-1998 12:15:06")
+				return
+			}
+			defer resetUtils()
+
+			id := NewID()
+			d, info, err := newProductHandle().Safely().SearchFor(M{
+				"_id": id,
+			}).FindAndModify(ModifyOptions{
+				Update:    newProduct(),
+				Upsert:    true,
+				ReturnNew: true,
+			})
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.Nil(err)
+			})
+			it("should have inserted the document", func(assert bdd.Assert) {
+				assert.Equal(id, info.UpsertedId)
+				assert.Equal(id, d.ID())
+			})
+			it("should have set created_on on the document inserted", func(assert bdd.Assert) {
+				assert.Equal(expectedNowInMilli(timeFmt("14-03-1998 12:15:06")), d.CreatedOn())
+			})
+		})
+	})
+}
+
+// Feature Write documents in bulk with Handle
+// - As a developer,
+// - I want to insert and modify documents in bulk using Handle,
+// - So that I can write lots of data with few calls to database.
+func Test_Write_documents_in_bulk_with_Handle(t *testing.T) {
+	defer cleanChanges()
+	given, like, s := bdd.Sentences().All()
+
+	given(t, "a linked ProductHandle p and %[1]v new products", func(when bdd.When, args ...interface{}) {
+		docs := make([]Documenter, args[0].(int))
+		for i := range docs {
+			docs[i] = newProduct()
+		}
+
+		when("result, err := p.InsertMany(docs) is called", func(it bdd.It) {
+			result, err := newProductHandle().Safely().InsertMany(docs)
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.Nil(err)
+			})
+			it("should have inserted %[1]v documents", func(assert bdd.Assert) {
+				assert.Equal(args[0].(int), result.Inserted)
+			})
+			it("should have generated IDs for all documents", func(assert bdd.Assert) {
+				for i := range docs {
+					assert.NotEqual(ObjectId(""), docs[i].ID())
+				}
+			})
+		})
+	}, like(
+		s(1), s(5),
+	))
+
+	given(t, "a linked ProductHandle p and products collection with documents "+colFixtures, func(when bdd.When, args ...interface{}) {
+		when("result, err := p.BulkWrite(%[1]v, ...) is called inserting an existing document", func(it bdd.It) {
+			result, err := newProductHandle().Safely().BulkWrite(args[0].(bool),
+				BulkInsert(newProduct()),
+				BulkInsert(newProductWithID(id1)),
+				BulkInsert(newProduct()),
+				BulkUpdate(M{"_id": fixture(2).ID()}, NewOps().Inc("views", 1)),
+				BulkRemove(M{"_id": fixture(3).ID()}),
+			)
+
+			it("should return an error", func(assert bdd.Assert) {
+				assert.Error(err)
+			})
+			it("should report an error on operation 1", func(assert bdd.Assert) {
+				assert.Equal(1, len(result.Errors))
+				assert.Equal(1, result.Errors[0].Index)
+			})
+			it("should have inserted %[2]v documents", func(assert bdd.Assert) {
+				assert.Equal(args[1].(int), result.Inserted)
+			})
+		})
+	}, like(
+		s(true, 1), s(false, 2),
+	))
+
+	given(t, "a linked ProductHandle p and a nil product", func(when bdd.When) {
+		var nilProduct *product
+
+		when("result, err := p.BulkWrite(true, %[1]s) is called", func(it bdd.It, args ...interface{}) {
+			result, err := newProductHandle().Safely().BulkWrite(true, args[1].(BulkOperation))
+
+			it("should return DocNotDefined", func(assert bdd.Assert) {
+				assert.Equal(DocNotDefined, err)
+			})
+			it("should have inserted no documents", func(assert bdd.Assert) {
+				assert.Equal(0, result.Inserted)
+			})
+		}, like(
+			s("BulkInsert(nil)", BulkInsert(nilProduct)),
+			s("BulkUpsert(M{}, nil)", BulkUpsert(M{}, nilProduct)),
+		))
+	})
+
+	given(t, "3 insert operations, and bulk errors %[1]v", func(when bdd.When, args ...interface{}) {
+		ops := []BulkOperation{BulkInsert(newProduct()), BulkInsert(newProduct()), BulkInsert(newProduct())}
+		errs := args[0].([]mgo.BulkErrorCase)
+
+		when("inserted(ops, errs, %[1]v) is called", func(it bdd.It, args ...interface{}) {
+			n := inserted(ops, errs, args[0].(bool))
+
+			it("should return %[2]v", func(assert bdd.Assert) {
+				assert.Equal(args[1].(int), n)
+			})
+		}, like(
+			s(true, args[1].(int)), s(false, args[2].(int)),
+		))
+	}, like(
+		s([]mgo.BulkErrorCase{{Index: 1}}, 1, 2),
+		s([]mgo.BulkErrorCase{{Index: -1}}, 0, 0),
+		s([]mgo.BulkErrorCase{{Index: 2}, {Index: -1}}, 0, 0),
+	))
+}
+
+// Feature Update various documents with Handle
+// - As a developer,
+// - I want to Update various documents matching a search using Handle,
+// - So that I can use Handler to update lots of data.
+func Test_Update_various_documents_with_Handle(t *testing.T) {
+	defer cleanChanges()
+	given, like, s := bdd.Sentences().All()
+
+	given(t, "a linked ProductHandle p searching for %[1]v", func(when bdd.When, args ...interface{}) {
+		when("info, err := p.UpdateAll(%[2]v) is called", func(it bdd.It) {
+			now = func() (t time.Time) {
+				t = timeFmt("14-03-1998 12:15:06")
+				return
+			}
+			defer resetUtils()
+
+			info, err := newProductHandle().Safely().SearchFor(args[0].(M)).UpdateAll(args[1])
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.Nil(err)
+			})
+			it("should have updated %[3]v documents", func(assert bdd.Assert) {
+				assert.Equal(args[2].(int), info.Updated)
+			})
+
+			da, errFind := newProductHandle().Safely().SearchFor(args[0].(M)).FindAll()
+
+			it("should have updated_on set on all documents", func(assert bdd.Assert) {
+				assert.Nil(errFind)
+				for i := range da {
+					assert.Equal(expectedNowInMilli(timeFmt("14-03-1998 12:15:06")), da[i].UpdatedOn())
+				}
+			})
+		})
+	}, like(
+		s(M{"_id": fixture(1).ID()}, M{"views": 1}, 1),
+		s(M{"_id": M{"$in": []ObjectId{fixture(1).ID(), fixture(2).ID()}}}, M{"$inc": M{"views": 1}}, 2),
+		s(M{}, NewOps().Set("views", 2), len(fixtures)),
+		s(M{"_id": fixture(1).ID()}, map[string]interface{}{"views": 3}, 1),
+		s(M{"_id": fixture(2).ID()}, map[string]interface{}{"$inc": map[string]interface{}{"views": 1}}, 1),
+	))
+
+	given(t, "a linked ProductHandle p", func(when bdd.When) {
+		when("info, err := p.UpdateAll('invalid') is called", func(it bdd.It) {
+			_, err := newProductHandle().Safely().UpdateAll("invalid")
+
+			it("should return ErrUpdateNotDefined", func(assert bdd.Assert) {
+				assert.Equal(ErrUpdateNotDefined, err)
+			})
+		})
+	})
+}
+
+// Feature Aggregate documents with Handle
+// - As a developer,
+// - I want to run aggregation pipelines using Handle,
+// - So that I can build reports without handwritten stages.
+func Test_Aggregate_documents_with_Handle(t *testing.T) {
+	given, like, s := bdd.Sentences().All()
+
+	given(t, "a ProductHandle p searching for %[1]v", func(when bdd.When, args ...interface{}) {
+		when("p.Aggregate(NewPipeline().Sort('-_id')) is called", func(it bdd.It) {
+			da, err := newProductHandle().Safely().SearchFor(args[0].(M)).Aggregate(NewPipeline().Sort("-_id"))
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.Nil(err)
+			})
+			it("should return %[2]v documents", func(assert bdd.Assert) {
+				assert.Len(da, args[1].(int))
+			})
+			it("should return documents in descending order", func(assert bdd.Assert) {
+				for i := 1; i < len(da); i++ {
+					assert.True(da[i-1].ID() > da[i].ID())
+				}
+			})
+		})
+
+		when("p.AggregateTo(NewPipeline().Group(nil, total), &result) is called", func(it bdd.It) {
+			var result []struct {
+				Total int `bson:"total"`
+			}
+			p := NewPipeline().Group(nil, M{"total": M{"$sum": 1}})
+			err := newProductHandle().Safely().SearchFor(args[0].(M)).AggregateTo(p, &result, AggregateOptions{
+				AllowDiskUse: true,
+				BatchSize:    1,
+			})
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.Nil(err)
+			})
+			it("should decode a total of %[2]v", func(assert bdd.Assert) {
+				total := 0
+				if len(result) == 1 {
+					total = result[0].Total
+				}
+				assert.Equal(args[1].(int), total)
+			})
+		})
+	}, like(
+		s(M{}, len(fixtures)),
+		s(M{"_id": fixture(1).ID()}, 1),
+		s(M{"_id": M{"$ne": fixture(1).ID()}}, 2),
+		s(M{"_id": ObjectIdHex(idE)}, 0),
+	))
+
+	given(t, "a ProductHandle p and a nil Pipeline", func(when bdd.When) {
+		when("p.Aggregate(nil) and p.AggregateTo(nil, &result) are called", func(it bdd.It) {
+			_, err := newProductHandle().Safely().Aggregate(nil)
+
+			var result []M
+			errTo := newProductHandle().Safely().AggregateTo(nil, &result)
+
+			it("should return ErrPipelineNotDefined", func(assert bdd.Assert) {
+				assert.Equal(ErrPipelineNotDefined, err)
+				assert.Equal(ErrPipelineNotDefined, errTo)
+			})
+		})
+	})
+
+	given(t, "a ProductHandle p and a Pipeline with a nil Pipeline on a facet", func(when bdd.When) {
+		when("p.Aggregate(NewPipeline().Facet(facets)) is called, with facet 'x' nil", func(it bdd.It) {
+			da, err := newProductHandle().Safely().Aggregate(NewPipeline().Facet(map[string]*Pipeline{
+				"all": NewPipeline().Match(M{}),
+				"x":   nil,
+			}))
+
+			it("should return ErrPipelineNotDefined", func(assert bdd.Assert) {
+				assert.Equal(ErrPipelineNotDefined, err)
+				assert.Nil(da)
+			})
+		})
+	})
+
+	given(t, "a Pipeline p", func(when bdd.When) {
+		when("p.Unwind('tags', true).Limit(5) is called", func(it bdd.It) {
+			stages := NewPipeline().Unwind("tags", true).Limit(5).Stages()
+
+			it("should have 2 stages", func(assert bdd.Assert) {
+				assert.Len(stages, 2)
+			})
+			it("should prefix the unwind path with '$'", func(assert bdd.Assert) {
+				assert.Equal(M{"$unwind": M{"path": "$tags", "preserveNullAndEmptyArrays": true}}, stages[0])
+			})
+			it("should limit to 5 documents", func(assert bdd.Assert) {
+				assert.Equal(M{"$limit": 5}, stages[1])
+			})
+		})
+	})
+}
+
+// Feature Verify fields of searches with strict Handle
+// - As a developer,
+// - I want to Handle to reject searches using unknown fields,
+// - So that typos on field names don't silently match nothing.
+func Test_Verify_fields_of_searches_with_strict_Handle(t *testing.T) {
+	given, like, s := bdd.Sentences().All()
+
+	given(t, "a strict ProductHandle p searching for %[1]v with options %[2]v", func(when bdd.When, args ...interface{}) {
+		when("p.FindAll() is called", func(it bdd.It) {
+			_, err := newProductHandle().Safely().Strict().SearchFor(args[0].(M)).FindAll(args[1].(QueryOptions))
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.Nil(err)
+			})
+		})
+	}, like(
+		s(M{"_id": fixture(1).ID()}, QueryOptions{}),
+		s(M{"$or": []M{{"_id": fixture(1).ID()}, {"created_on": M{"$gt": 0}}}}, QueryOptions{Sort: []string{"-updated_on"}}),
+		s(M{"created_on": M{"$exists": true}}, QueryOptions{Include: []string{"_id", "created_on"}}),
+	))
+
+	given(t, "a strict ProductHandle p searching for %[1]v with options %[2]v", func(when bdd.When, args ...interface{}) {
+		when("p.FindAll() is called", func(it bdd.It) {
+			_, err := newProductHandle().Safely().Strict().SearchFor(args[0].(M)).FindAll(args[1].(QueryOptions))
+
+			it("should return an UnknownFieldError on %[3]v of '%[4]v'", func(assert bdd.Assert) {
+				assert.Equal(&UnknownFieldError{
+					Path:  args[3].(string),
+					Usage: args[2].(string),
+					Type:  "*mongo.product",
+				}, err)
+			})
+		})
+	}, like(
+		s(M{"name": "bread"}, QueryOptions{}, "filter", "name"),
+		s(M{"$and": []M{{"_id": fixture(1).ID()}, {"creatd_on": 0}}}, QueryOptions{}, "filter", "creatd_on"),
+		s(M{"_id": fixture(1).ID()}, QueryOptions{Sort: []string{"-price"}}, "sort", "price"),
+		s(M{"_id": fixture(1).ID()}, QueryOptions{Exclude: []string{"price"}}, "projection", "price"),
+	))
+
+	given(t, "a not strict ProductHandle p searching for M{'name': 'bread'}", func(when bdd.When) {
+		when("p.FindAll() is called", func(it bdd.It) {
+			da, err := newProductHandle().Safely().SearchFor(M{"name": "bread"}).FindAll()
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.Nil(err)
+			})
+			it("should return no documents", func(assert bdd.Assert) {
+				assert.Empty(da)
+			})
+		})
+	})
+}
+
+// Feature Translate driver errors with Handle
+// - As a developer,
+// - I want to Handle to return typed errors on operations,
+// - So that I can check them with errors.Is and errors.As.
+func Test_Translate_driver_errors_with_Handle(t *testing.T) {
+	defer cleanChanges()
+	given, like, s := bdd.Sentences().All()
+
+	given(t, "a ProductHandle p searching for an unknown ID", func(when bdd.When) {
+		when("p.Find() is called", func(it bdd.It) {
+			_, err := newProductHandle().Safely().SearchFor(M{"_id": ObjectIdHex(idE)}).Find()
+
+			it("should return ErrNotFound", func(assert bdd.Assert) {
+				assert.True(errors.Is(err, ErrNotFound))
+				assert.Contains(err.Error(), "not found")
+			})
+			it("should keep matching mgo.ErrNotFound", func(assert bdd.Assert) {
+				assert.True(errors.Is(err, mgo.ErrNotFound))
+				assert.Equal(mgo.ErrNotFound, err)
+			})
+		})
+
+		when("p.Remove(id) is called", func(it bdd.It) {
+			err := newProductHandle().Safely().Remove(ObjectIdHex(idE))
+
+			it("should return ErrNotFound", func(assert bdd.Assert) {
+				assert.True(errors.Is(err, ErrNotFound))
+			})
+		})
+	})
+
+	given(t, "a ProductHandle p with the ID of a document stored", func(when bdd.When) {
+		p := newProductHandle()
+		p.Document().IDV = fixture(1).ID()
+
+		when("p.Insert() is called", func(it bdd.It) {
+			err := p.Safely().Insert()
+
+			it("should return a DuplicateKeyError on index _id_", func(assert bdd.Assert) {
+				var dup *DuplicateKeyError
+				assert.True(errors.As(err, &dup))
+				if dup != nil {
+					assert.Equal("_id_", dup.Index)
+					assert.Contains(dup.Key, fixture(1).ID().Hex())
+				}
+			})
+		})
+	})
+
+	given(t, "the errors returned by a Validate method", func(when bdd.When) {
+		errPrice := errors.New("price must be positive")
+
+		when("validationErr is called with a plain error", func(it bdd.It) {
+			err := validationErr(errPrice)
+
+			it("should return a ValidationError wrapping it", func(assert bdd.Assert) {
+				var verr *ValidationError
+				assert.True(errors.As(err, &verr))
+				assert.True(errors.Is(err, errPrice))
+			})
+		})
+
+		when("validationErr is called with a ValidationError", func(it bdd.It) {
+			verr := &ValidationError{Fields: map[string]string{"price": "must be positive", "name": "required"}}
+			err := validationErr(verr)
+
+			it("should return it unchanged", func(assert bdd.Assert) {
+				assert.Equal(verr, err)
+			})
+			it("should describe each field", func(assert bdd.Assert) {
+				assert.Equal("invalid document: name: required; price: must be positive", err.Error())
+			})
+		})
+	})
+
+	given(t, "a driver error %[1]v", func(when bdd.When, args ...interface{}) {
+		when("wrapErr is called", func(it bdd.It) {
+			err := args[0].(error)
+			wrapErr(&err)
+
+			it("should return a %[2]T", func(assert bdd.Assert) {
+				assert.Equal(reflect.TypeOf(args[1]), reflect.TypeOf(err))
+				assert.True(errors.Is(err, args[0].(error)))
+			})
+		})
+	}, like(
+		s(errors.New("no reachable servers"), &ConnectionError{}),
+		s(&mgo.QueryError{Code: 50, Message: "operation exceeded time limit"}, &TimeoutError{}),
+		s(&mgo.LastError{Code: 11000, Err: "E11000 duplicate key error collection: testing.products index: name_1 dup key: { : \"bread\" }"}, &DuplicateKeyError{}),
+	))
+}
+
+// Feature Run operations with context with Handle
+// - As a developer,
+// - I want to run Handle operations respecting a context,
+// - So that cancellation and deadlines stop slow operations.
+func Test_Run_operations_with_context_with_Handle(t *testing.T) {
+	defer cleanChanges()
+	given := bdd.Sentences().Given()
+
+	given(t, "a ProductHandle p searching for a document stored, and a context ctx with deadline", func(when bdd.When) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		when("p.FindCtx(ctx) is called", func(it bdd.It) {
+			doc, err := newProductHandle().Safely().SearchFor(M{"_id": fixture(1).ID()}).FindCtx(ctx)
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.Nil(err)
+			})
+			it("should return the document", func(assert bdd.Assert) {
+				assert.Equal(fixture(1).ID(), doc.ID())
+			})
+		})
+
+		when("p.FindAllCtx(ctx) and p.CountCtx(ctx) are called", func(it bdd.It) {
+			da, errFind := newProductHandle().Safely().SearchFor(M{"_id": fixture(1).ID()}).FindAllCtx(ctx)
+			n, errCount := newProductHandle().Safely().CountCtx(ctx)
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.Nil(errFind)
+				assert.Nil(errCount)
+			})
+			it("should find 1 document, and count all", func(assert bdd.Assert) {
+				assert.Len(da, 1)
+				assert.Equal(len(fixtures), n)
+			})
+		})
+
+		when("p.InsertCtx(ctx) and p.RemoveCtx(ctx, id) are called", func(it bdd.It) {
+			p := newProductHandle()
+			errInsert := p.InsertCtx(ctx)
+			errRemove := newProductHandle().Safely().RemoveCtx(ctx, p.Document().ID())
+			p.Close()
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.Nil(errInsert)
+				assert.Nil(errRemove)
+			})
+		})
+
+		when("p.FindPageCtx(ctx), p.EachCtx(ctx, f) and p.DistinctCtx(ctx, '_id', &ids) are called", func(it bdd.It) {
+			da, total, errPage := newProductHandle().Safely().FindPageCtx(ctx, QueryOptions{Limit: 1})
+
+			var seen int
+			errEach := newProductHandle().Safely().EachCtx(ctx, func(Documenter) error {
+				seen++
+				return nil
+			})
+
+			var ids []ObjectId
+			errDistinct := newProductHandle().Safely().DistinctCtx(ctx, "_id", &ids)
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.Nil(errPage)
+				assert.Nil(errEach)
+				assert.Nil(errDistinct)
+			})
+			it("should find a page of 1 document, and all documents on each and distinct", func(assert bdd.Assert) {
+				assert.Len(da, 1)
+				assert.Equal(len(fixtures), total)
+				assert.Equal(len(fixtures), seen)
+				assert.Len(ids, len(fixtures))
+			})
+		})
+
+		when("p.InsertManyCtx(ctx, docs) is called", func(it bdd.It) {
+			docs := []Documenter{newProduct(), newProduct()}
+			result, err := newProductHandle().Safely().InsertManyCtx(ctx, docs)
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.Nil(err)
+			})
+			it("should have inserted 2 documents, generating their IDs", func(assert bdd.Assert) {
+				assert.Equal(2, result.Inserted)
+				assert.NotEqual(ObjectId(""), docs[0].ID())
+				assert.NotEqual(ObjectId(""), docs[1].ID())
+			})
+		})
+	})
+
+	given(t, "a ProductHandle p, and a context ctx cancelled by the function iterating", func(when bdd.When) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		when("p.EachCtx(ctx, f) is called, with f cancelling ctx on first document", func(it bdd.It) {
+			var seen int
+			err := newProductHandle().Safely().EachCtx(ctx, func(Documenter) error {
+				seen++
+				cancel()
+				return nil
+			})
+			seenOnReturn := seen
+
+			it("should return context.Canceled", func(assert bdd.Assert) {
+				assert.Equal(context.Canceled, err)
+			})
+			it("should have called f once, before returning", func(assert bdd.Assert) {
+				assert.Equal(1, seenOnReturn)
+				assert.Equal(1, seen)
+			})
+		})
+
+		when("p.EachCtx(ctx, f) is called, with f panicking", func(it bdd.It) {
+			var recovered interface{}
+			func() {
+				defer func() {
+					recovered = recover()
+				}()
+				_ = newProductHandle().Safely().EachCtx(context.Background(), func(Documenter) error {
+					panic("bug on f")
+				})
+			}()
+
+			it("should panic on the goroutine of caller", func(assert bdd.Assert) {
+				assert.Equal("bug on f", recovered)
+			})
+		})
+	})
+
+	given(t, "a ProductHandle p, and a cancelled context ctx", func(when bdd.When) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		when("p.FindAllCtx(ctx) is called", func(it bdd.It) {
+			da, err := newProductHandle().Safely().FindAllCtx(ctx)
+
+			it("should return context.Canceled", func(assert bdd.Assert) {
+				assert.Equal(context.Canceled, err)
+			})
+			it("should return no documents", func(assert bdd.Assert) {
+				assert.Nil(da)
+			})
+		})
+
+		when("p.InsertCtx(ctx) is called", func(it bdd.It) {
+			p := newProductHandle().Safely()
+			err := p.InsertCtx(ctx)
+
+			it("should return context.Canceled", func(assert bdd.Assert) {
+				assert.Equal(context.Canceled, err)
+			})
+			it("should leave the document untouched", func(assert bdd.Assert) {
+				assert.Equal(ObjectId(""), p.Document().ID())
+			})
+		})
+	})
+
+	given(t, "a ProductHandle p, and an expired context ctx", func(when bdd.When) {
+		ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+		defer cancel()
+
+		when("p.CountCtx(ctx) is called", func(it bdd.It) {
+			_, err := newProductHandle().Safely().CountCtx(ctx)
+
+			it("should return a TimeoutError", func(assert bdd.Assert) {
+				var timeout *TimeoutError
+				assert.True(errors.As(err, &timeout))
+				assert.True(errors.Is(err, context.DeadlineExceeded))
+			})
+		})
+	})
+}
@@ -1,6 +1,7 @@
 package mongo
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"testing"
@@ -63,6 +64,7 @@ type product struct {
 	IDV        ObjectId `bson:"_id"`
 	CreatedOnV int64    `bson:"created_on"`
 	UpdatedOnV int64    `bson:"updated_on"`
+	projection M
 }
 
 // newProduct returns a empty product.
@@ -93,6 +95,31 @@ func (p *product) Validate() (err error) {
 	return
 }
 
+// invalidProduct it's a product refused by its Validate method.
+type invalidProduct struct {
+	product `bson:",inline"`
+}
+
+// Validate refuses the invalidProduct.
+func (p *invalidProduct) Validate() (err error) {
+	err = errors.New("invalid product")
+	return
+}
+
+// countingProduct it's a product counting the documents created by
+// New, to be decoded.
+type countingProduct struct {
+	product `bson:",inline"`
+	decoded *int
+}
+
+// New creates a new countingProduct, sharing the counter.
+func (p *countingProduct) New() (doc Documenter) {
+	*p.decoded++
+	doc = &countingProduct{decoded: p.decoded}
+	return
+}
+
 // Map translates a product to a M object, more easily read by mgo
 // methods.
 func (p *product) Map() (out M, err error) {
@@ -144,6 +171,24 @@ func (p *product) CalculateUpdatedOn() {
 	p.UpdatedOnV = NowInMilli()
 }
 
+// SetProjection stores the projection used to find the product.
+func (p *product) SetProjection(proj M) {
+	p.projection = proj
+}
+
+// Projection returns the projection used to find the product.
+func (p *product) Projection() (proj M) {
+	proj = p.projection
+	return
+}
+
+// item it's a type embedding the Document struct, declaring only its
+// own fields.
+type item struct {
+	Document `bson:",inline"`
+	NameV    string `bson:"name"`
+}
+
 // productHandle it's a type embedding the Handle struct, it's capable
 // of storing Products.
 type productHandle struct {
@@ -167,6 +212,14 @@ func (p *productHandle) Safely() (ph *productHandle) {
 	return
 }
 
+// Strict sets Handler to verify fields used on searches, returns
+// Handle for chaining purposes.
+func (p *productHandle) Strict() (ph *productHandle) {
+	p.Handle.Strict()
+	ph = p
+	return
+}
+
 // Clean documents and search map values, returns Handle for chaining
 // purposes.
 func (p *productHandle) Clean() (ph *productHandle) {
@@ -177,9 +230,9 @@ func (p *productHandle) Clean() (ph *productHandle) {
 
 // Find search on connected collection for a document matching data
 // stored on productHandle and returns it.
-func (p *productHandle) Find() (prod *product, err error) {
+func (p *productHandle) Find(opts ...QueryOptions) (prod *product, err error) {
 	var doc Documenter
-	doc, err = p.Handle.Find()
+	doc, err = p.Handle.Find(opts...)
 	prod = doc.(*product)
 	return
 }
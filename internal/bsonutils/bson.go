@@ -39,6 +39,7 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/globalsign/mgo/bson"
 )
@@ -106,12 +107,22 @@ func Marshal(in interface{}) (out []byte, err error) {
 	return MarshalBuffer(in, make([]byte, 0, initialBufferSize))
 }
 
+// MarshalWith behaves the same way as Marshal, using the options
+// received instead of the global defaults. It's safe to be called
+// concurrently with different options.
+func MarshalWith(opts Options, in interface{}) (out []byte, err error) {
+	defer handleErr(&err)
+	e := &encoder{make([]byte, 0, initialBufferSize), opts}
+	e.addDoc(reflect.ValueOf(in))
+	return e.out, nil
+}
+
 // MarshalBuffer behaves the same way as Marshal, except that instead of
 // allocating a new byte slice it tries to use the received byte slice and
 // only allocates more memory if necessary to fit the marshaled value.
 func MarshalBuffer(in interface{}, buf []byte) (out []byte, err error) {
 	defer handleErr(&err)
-	e := &encoder{buf}
+	e := &encoder{buf, defaultOptions()}
 	e.addDoc(reflect.ValueOf(in))
 	return e.out, nil
 }
@@ -152,6 +163,12 @@ func MarshalBuffer(in interface{}, buf []byte) (out []byte, err error) {
 //
 // Pointer values are initialized when necessary.
 func Unmarshal(in []byte, out interface{}) (err error) {
+	return UnmarshalWith(defaultOptions(), in, out)
+}
+
+// UnmarshalWith behaves the same way as Unmarshal, using the options
+// received to find the keys of struct fields.
+func UnmarshalWith(opts Options, in []byte, out interface{}) (err error) {
 	if raw, ok := out.(*bson.Raw); ok {
 		raw.Kind = 3
 		raw.Data = in
@@ -164,6 +181,8 @@ func Unmarshal(in []byte, out interface{}) (err error) {
 		fallthrough
 	case reflect.Map:
 		d := newDecoder(in)
+		d.keyCase = opts.KeyCase
+		d.registry = opts.Registry
 		d.readDocTo(v)
 		if d.i < len(d.in) {
 			return errors.New("document is corrupted")
@@ -194,7 +213,14 @@ type fieldInfo struct {
 	Inline    []int
 }
 
-var structMap = make(map[reflect.Type]*structInfo)
+// structKey it's the key of structs info cached, that depends on the
+// case of keys derived from field names.
+type structKey struct {
+	t       reflect.Type
+	keyCase KeyCase
+}
+
+var structMap = make(map[structKey]*structInfo)
 var structMapMutex sync.RWMutex
 
 type externalPanic string
@@ -204,8 +230,12 @@ func (e externalPanic) String() string {
 }
 
 func getStructInfo(st reflect.Type) (*structInfo, error) {
+	return getStructInfoWith(st, LowerCase)
+}
+
+func getStructInfoWith(st reflect.Type, keyCase KeyCase) (*structInfo, error) {
 	structMapMutex.RLock()
-	sinfo, found := structMap[st]
+	sinfo, found := structMap[structKey{st, keyCase}]
 	structMapMutex.RUnlock()
 	if found {
 		return sinfo, nil
@@ -275,7 +305,7 @@ func getStructInfo(st reflect.Type) (*structInfo, error) {
 				field.Type = field.Type.Elem()
 				fallthrough
 			case reflect.Struct:
-				sinfo, err := getStructInfo(field.Type)
+				sinfo, err := getStructInfoWith(field.Type, keyCase)
 				if err != nil {
 					return nil, err
 				}
@@ -301,7 +331,7 @@ func getStructInfo(st reflect.Type) (*structInfo, error) {
 		if tag != "" {
 			info.Key = tag
 		} else {
-			info.Key = strings.ToLower(field.Name)
+			info.Key = keyCase.key(field.Name)
 		}
 
 		if _, found = fieldsMap[info.Key]; found {
@@ -319,20 +349,34 @@ func getStructInfo(st reflect.Type) (*structInfo, error) {
 		reflect.New(st).Elem(),
 	}
 	structMapMutex.Lock()
-	structMap[st] = sinfo
+	structMap[structKey{st, keyCase}] = sinfo
 	structMapMutex.Unlock()
 	return sinfo, nil
 }
 
-// useOmitEmptyAsDefault defines if OmitEmpty should be used on all fields.
-var useOmitEmptyAsDefault = false
+// useOmitEmptyAsDefault defines if OmitEmpty should be used on all
+// fields, by functions not receiving options. It's 1 when enabled.
+var useOmitEmptyAsDefault int32
 
 // SetOmitEmptyAsDefault enable or disables tag omitempty for all fields.
+// It only affects the functions not receiving options, like Marshal and
+// ToMap.
+//
+// Deprecated: the setting is global, changing the behavior of every
+// goroutine marshalling values at once. It's kept for compatibility,
+// use MarshalWith with Options.OmitEmpty instead.
 func SetOmitEmptyAsDefault(state bool) {
-	useOmitEmptyAsDefault = state
+	var v int32
+	if state {
+		v = 1
+	}
+	atomic.StoreInt32(&useOmitEmptyAsDefault, v)
 }
 
 // IsOmitEmptyAsDefault check if omitempty is enable for all fields.
+//
+// Deprecated: kept for compatibility, use MarshalWith with
+// Options.OmitEmpty instead.
 func IsOmitEmptyAsDefault() bool {
-	return useOmitEmptyAsDefault
+	return atomic.LoadInt32(&useOmitEmptyAsDefault) == 1
 }
@@ -0,0 +1,376 @@
+// Copyright 2009 Dênnis Dantas de Sousa. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bsonutils
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sync"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+// converter translates a value to the one found when it's marshalled
+// and unmarshalled onto an interface{}, without serializing it.
+type converter func(v reflect.Value, minSize bool, opts *Options) interface{}
+
+// converters caches the converter compiled for each type.
+var converters sync.Map // map[reflect.Type]converter
+
+// specialStructs are the struct types with their own BSON encoding,
+// translated by serializing them.
+var specialStructs = map[reflect.Type]bool{
+	typeRaw:                           true,
+	typeBinary:                        true,
+	typeTime:                          true,
+	typeURL:                           true,
+	reflect.TypeOf(decimal128{}):      true,
+	reflect.TypeOf(bson.DBPointer{}):  true,
+	reflect.TypeOf(bson.RegEx{}):      true,
+	reflect.TypeOf(bson.JavaScript{}): true,
+	reflect.TypeOf(undefined{}):       true,
+}
+
+// ToMap translates the in value, a map or a struct, to the bson.M
+// found when it's marshalled and unmarshalled onto an interface{}, but
+// without serializing it. The translation of each type is compiled
+// once and cached, falling back to serialization only for values with
+// special encodings, like Getters or time.Time.
+func ToMap(in interface{}) (out bson.M, err error) {
+	return ToMapWith(defaultOptions(), in)
+}
+
+// ToMapWith behaves the same way as ToMap, using the options received,
+// like MarshalWith does.
+func ToMapWith(opts Options, in interface{}) (out bson.M, err error) {
+	defer handleErr(&err)
+
+	v := reflect.ValueOf(in)
+	for v.Kind() == reflect.Ptr && !v.IsNil() && getterStyle(v.Type()) == getterNone {
+		v = v.Elem()
+	}
+
+	if (v.Kind() == reflect.Struct && !specialStructs[v.Type()] || v.Kind() == reflect.Map) && getterStyle(v.Type()) == getterNone {
+		out, _ = converterFor(v.Type())(v, false, &opts).(bson.M)
+	} else {
+		var buf []byte
+		var target interface{}
+		if buf, err = MarshalWith(opts, in); err == nil {
+			if err = Unmarshal(buf, &target); err == nil {
+				out, _ = target.(bson.M)
+			}
+		}
+	}
+
+	return
+}
+
+// converterFor returns the converter for type t, compiling it when
+// needed. Recursive types receive a converter waiting for the one
+// being compiled.
+func converterFor(t reflect.Type) (c converter) {
+	if cached, ok := converters.Load(t); ok {
+		c = cached.(converter)
+	} else {
+		var wg sync.WaitGroup
+		var compiled converter
+
+		wg.Add(1)
+		cached, loaded := converters.LoadOrStore(t, converter(func(v reflect.Value, minSize bool, opts *Options) interface{} {
+			wg.Wait()
+			return compiled(v, minSize, opts)
+		}))
+
+		if loaded {
+			c = cached.(converter)
+		} else {
+			compiled = compileConverter(t)
+			wg.Done()
+			converters.Store(t, compiled)
+			c = compiled
+		}
+	}
+	return
+}
+
+// converterWith returns the converter for type t, using the encoder
+// registered for it on the Registry of opts, if any.
+func converterWith(t reflect.Type, opts *Options) (c converter) {
+	if enc := opts.Registry.encoder(t); enc != nil {
+		c = registeredConverter(enc)
+	} else {
+		c = converterFor(t)
+	}
+	return
+}
+
+// registeredConverter returns the converter translating values with the
+// encoder enc, and the value it returns with its own converter.
+func registeredConverter(enc EncodeFunc) (c converter) {
+	c = func(v reflect.Value, minSize bool, opts *Options) (out interface{}) {
+		encv, err := enc(v)
+		if err != nil {
+			panic(err)
+		}
+		if encv != nil {
+			ev := reflect.ValueOf(encv)
+			out = converterWith(ev.Type(), opts)(ev, minSize, opts)
+		}
+		return
+	}
+	return
+}
+
+// compileConverter creates the converter for type t, mirroring the
+// encoding and decoding of its values.
+func compileConverter(t reflect.Type) (c converter) {
+	if getterStyle(t) != getterNone {
+		c = serializedConverter
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Interface:
+		c = interfaceConverter
+	case reflect.Ptr:
+		c = ptrConverter(t)
+	case reflect.String:
+		c = stringConverter(t)
+	case reflect.Bool:
+		c = func(v reflect.Value, _ bool, _ *Options) interface{} {
+			return v.Bool()
+		}
+	case reflect.Float32, reflect.Float64:
+		c = func(v reflect.Value, _ bool, _ *Options) interface{} {
+			return v.Float()
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		c = intConverter(t)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		c = uintConverter
+	case reflect.Slice:
+		c = sliceConverter(t)
+	case reflect.Map:
+		c = mapConverter(t)
+	case reflect.Struct:
+		c = structConverter(t)
+	default:
+		c = serializedConverter
+	}
+
+	return
+}
+
+// serializedConverter translates v marshalling it as the element of a
+// document, and unmarshalling the document.
+func serializedConverter(v reflect.Value, minSize bool, opts *Options) interface{} {
+	e := &encoder{make([]byte, 0, initialBufferSize), *opts}
+	start := e.reserveInt32()
+	e.addElem("v", v, minSize)
+	e.addBytes(0)
+	e.setInt32(start, int32(len(e.out)-start))
+
+	var m bson.M
+	if err := Unmarshal(e.out, &m); err != nil {
+		panic(err)
+	}
+	return m["v"]
+}
+
+// interfaceConverter translates v with the converter of the value it
+// holds.
+func interfaceConverter(v reflect.Value, minSize bool, opts *Options) (out interface{}) {
+	if !v.IsNil() {
+		out = converterWith(v.Elem().Type(), opts)(v.Elem(), minSize, opts)
+	}
+	return
+}
+
+// ptrConverter returns the converter of pointers of type t.
+func ptrConverter(t reflect.Type) (c converter) {
+	c = func(v reflect.Value, minSize bool, opts *Options) (out interface{}) {
+		if !v.IsNil() {
+			out = converterWith(t.Elem(), opts)(v.Elem(), minSize, opts)
+		}
+		return
+	}
+	return
+}
+
+// stringConverter returns the converter of strings of type t.
+func stringConverter(t reflect.Type) (c converter) {
+	switch t {
+	case typeObjectId:
+		c = func(v reflect.Value, _ bool, _ *Options) interface{} {
+			s := v.String()
+			if len(s) != 12 {
+				panic("ObjectIDs must be exactly 12 bytes long (got " + itoa(len(s)) + ")")
+			}
+			return bson.ObjectId(s)
+		}
+	case typeSymbol, typeJSONNumber:
+		c = serializedConverter
+	default:
+		c = func(v reflect.Value, _ bool, _ *Options) interface{} {
+			return v.String()
+		}
+	}
+	return
+}
+
+// intConverter returns the converter of integers of type t. Values
+// stored as int32 are found as int.
+func intConverter(t reflect.Type) (c converter) {
+	switch t {
+	case typeMongoTimestamp, typeOrderKey, typeTimeDuration:
+		c = serializedConverter
+	default:
+		c = func(v reflect.Value, minSize bool, _ *Options) (out interface{}) {
+			i := v.Int()
+			if (minSize || v.Kind() != reflect.Int64) && i >= math.MinInt32 && i <= math.MaxInt32 {
+				out = int(i)
+			} else {
+				out = i
+			}
+			return
+		}
+	}
+	return
+}
+
+// uintConverter translates unsigned integers. Values stored as int32
+// are found as int.
+func uintConverter(v reflect.Value, minSize bool, _ *Options) (out interface{}) {
+	u := v.Uint()
+	if int64(u) < 0 {
+		panic("BSON has no uint64 type, and value is too large to fit correctly in an int64")
+	} else if u <= math.MaxInt32 && (minSize || v.Kind() <= reflect.Uint32) {
+		out = int(u)
+	} else {
+		out = int64(u)
+	}
+	return
+}
+
+// sliceConverter returns the converter of slices of type t. Byte
+// slices are found as []byte, the others as []interface{}. Nil slices
+// are found as nil with the option NilSliceAsNull.
+func sliceConverter(t reflect.Type) (c converter) {
+	var elems converter
+	switch et := t.Elem(); {
+	case et.Kind() == reflect.Uint8:
+		elems = func(v reflect.Value, _ bool, _ *Options) interface{} {
+			return append([]byte{}, v.Bytes()...)
+		}
+	case et == typeDocElem || et == typeRawDocElem:
+		elems = serializedConverter
+	default:
+		elems = func(v reflect.Value, _ bool, opts *Options) interface{} {
+			elem := converterWith(et, opts)
+			out := make([]interface{}, v.Len())
+			for i := range out {
+				out[i] = elem(v.Index(i), false, opts)
+			}
+			return out
+		}
+	}
+
+	c = func(v reflect.Value, minSize bool, opts *Options) (out interface{}) {
+		if !v.IsNil() || !opts.NilSliceAsNull {
+			out = elems(v, minSize, opts)
+		}
+		return
+	}
+	return
+}
+
+// mapConverter returns the converter of maps of type t. Maps are found
+// as bson.M.
+func mapConverter(t reflect.Type) (c converter) {
+	if t.Key().Kind() != reflect.String {
+		c = serializedConverter
+	} else {
+		c = func(v reflect.Value, minSize bool, opts *Options) (out interface{}) {
+			if opts.Registry.encoder(t.Key()) != nil {
+				// Keys translated by encoders are stored as strings.
+				out = serializedConverter(v, minSize, opts)
+			} else {
+				m := make(bson.M, v.Len())
+				convertMapTo(m, v, opts)
+				out = m
+			}
+			return
+		}
+	}
+	return
+}
+
+// convertMapTo stores the elements of map v on out, translated.
+func convertMapTo(out bson.M, v reflect.Value, opts *Options) {
+	elem := converterWith(v.Type().Elem(), opts)
+
+	iter := v.MapRange()
+	for iter.Next() {
+		k := iter.Key().String()
+		if arrayOps[k] {
+			// Byte slices are stored as arrays on these keys.
+			out[k] = serializedConverter(iter.Value(), false, opts)
+		} else {
+			out[k] = elem(iter.Value(), false, opts)
+		}
+	}
+}
+
+// structConverter returns the converter of structs of type t. Structs
+// are found as bson.M, with the keys defined by their tags, or derived
+// with the KeyCase option.
+func structConverter(t reflect.Type) (c converter) {
+	if specialStructs[t] {
+		c = serializedConverter
+		return
+	}
+
+	c = func(v reflect.Value, _ bool, opts *Options) interface{} {
+		sinfo, err := getStructInfoWith(t, opts.KeyCase)
+		if err != nil {
+			panic(err)
+		}
+
+		out := make(bson.M, len(sinfo.FieldsList))
+
+		if sinfo.InlineMap >= 0 {
+			m := v.Field(sinfo.InlineMap)
+			for _, k := range m.MapKeys() {
+				if _, found := sinfo.FieldsMap[k.String()]; found {
+					panic(fmt.Sprintf("Can't have key %q in inlined map; conflicts with struct field", k.String()))
+				}
+			}
+			convertMapTo(out, m, opts)
+		}
+
+		for _, info := range sinfo.FieldsList {
+			var value reflect.Value
+			if info.Inline == nil {
+				value = v.Field(info.Num)
+			} else {
+				field, errField := safeFieldByIndex(v, info.Inline)
+				if errField != nil {
+					continue
+				}
+				value = field
+			}
+
+			if (info.OmitEmpty || opts.OmitEmpty || opts.OmitEmptyIDs && value.Type() == typeObjectId) && isZero(value) {
+				continue
+			}
+
+			out[info.Key] = converterWith(value.Type(), opts)(value, info.MinSize || opts.MinSize, opts)
+		}
+
+		return out
+	}
+	return
+}
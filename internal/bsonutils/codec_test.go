@@ -0,0 +1,223 @@
+// +build !acceptance
+
+package bsonutils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ddspog/bdd"
+	"github.com/globalsign/mgo/bson"
+)
+
+// status it's a named type, translated as its underlying type.
+type status string
+
+// stamp it's a type implementing Getter, translated serializing it.
+type stamp struct {
+	At int64
+}
+
+// GetBSON returns the value stored for stamp.
+func (s stamp) GetBSON() (interface{}, error) {
+	return bson.M{"at": s.At * 1000}, nil
+}
+
+// base it's a type inlined on documents.
+type base struct {
+	Kind  string `bson:"kind"`
+	Level uint8  `bson:"level"`
+}
+
+// tag it's a type used as elements of slices.
+type tag struct {
+	Name  string `bson:"name"`
+	Count uint16 `bson:"count"`
+}
+
+// node it's a recursive type.
+type node struct {
+	Value    int     `bson:"value"`
+	Children []*node `bson:"children"`
+}
+
+// sample it's a document declaring fields of most kinds.
+type sample struct {
+	ID       bson.ObjectId          `bson:"_id"`
+	Name     string                 `bson:"name"`
+	Status   status                 `bson:"status"`
+	Active   bool                   `bson:"active"`
+	Small    int64                  `bson:"small,minsize"`
+	Big      int64                  `bson:"big"`
+	Int      int                    `bson:"int"`
+	Uint     uint64                 `bson:"uint"`
+	Price    float32                `bson:"price"`
+	Data     []byte                 `bson:"data"`
+	Tags     []tag                  `bson:"tags"`
+	Names    []string               `bson:"names"`
+	Meta     map[string]interface{} `bson:"meta"`
+	Ptr      *tag                   `bson:"ptr"`
+	Any      interface{}            `bson:"any"`
+	When     time.Time              `bson:"when"`
+	Wait     time.Duration          `bson:"wait"`
+	Stamp    stamp                  `bson:"stamp"`
+	Tree     node                   `bson:"tree"`
+	Empty    string                 `bson:"empty,omitempty"`
+	Hidden   string                 `bson:"-"`
+	base     `bson:",inline"`
+	Extra    map[string]interface{} `bson:",inline"`
+	internal int
+}
+
+// newSample returns a sample with all fields defined.
+func newSample() (s *sample) {
+	s = &sample{
+		ID:     bson.ObjectIdHex("000070726f64756374316964"),
+		Name:   "bread",
+		Status: "sold",
+		Active: true,
+		Small:  10,
+		Big:    1 << 40,
+		Int:    3,
+		Uint:   1 << 33,
+		Price:  2.5,
+		Data:   []byte("data"),
+		Tags:   []tag{{"a", 1}, {"b", 2}},
+		Names:  []string{"x", "y"},
+		Meta:   map[string]interface{}{"a": 1, "b": []int{1, 2}, "c": map[string]string{"d": "e"}},
+		Ptr:    &tag{"p", 3},
+		Any:    tag{"any", 4},
+		When:   time.Date(2018, 5, 1, 10, 0, 0, 123456789, time.UTC),
+		Wait:   time.Second,
+		Stamp:  stamp{At: 5},
+		Tree:   node{Value: 1, Children: []*node{{Value: 2}, {Value: 3, Children: []*node{}}}},
+		base:   base{"inline", 5},
+		Extra:  map[string]interface{}{"extra": "value"},
+	}
+	return
+}
+
+// roundTrip translates in to bson.M marshalling and unmarshalling it
+// with opts.
+func roundTrip(opts Options, in interface{}) (out bson.M, err error) {
+	var buf []byte
+	var target interface{}
+	if buf, err = MarshalWith(opts, in); err == nil {
+		if err = Unmarshal(buf, &target); err == nil {
+			out = target.(bson.M)
+		}
+	}
+	return
+}
+
+// Feature Translate values to maps with ToMap
+// - As a developer,
+// - I want to translate documents to maps without serializing them,
+// - So that I get the same maps of a round trip, faster.
+func Test_Translate_values_to_maps_with_ToMap(t *testing.T) {
+	given, like, s := bdd.Sentences().All()
+
+	given(t, "the value %[1]v and the options %+[2]v", func(when bdd.When, args ...interface{}) {
+		opts := args[1].(Options)
+
+		when("out, err := ToMapWith(opts, value) is called", func(it bdd.It) {
+			out, err := ToMapWith(opts, args[0])
+			expected, errExpected := roundTrip(opts, args[0])
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.NoError(err)
+				assert.NoError(errExpected)
+			})
+			it("should return the map of a round trip", func(assert bdd.Assert) {
+				assert.Equal(expected, out)
+			})
+		})
+	}, like(
+		s(newSample(), Options{}), s(newSample(), Options{OmitEmpty: true}),
+		s(&sample{ID: newSample().ID}, Options{}), s(&sample{}, Options{OmitEmpty: true}),
+		s(*newSample(), Options{MinSize: true, KeyCase: SnakeCase}),
+		s(&sample{ID: newSample().ID}, Options{NilSliceAsNull: true, KeyCase: CamelCase}),
+		s(bson.M{"a": 1, "b": newSample()}, Options{OmitEmpty: true}),
+		s(stamp{At: 1}, Options{}),
+	))
+
+	given(t, "a struct with an inline map conflicting with its fields", func(when bdd.When) {
+		v := sample{Extra: map[string]interface{}{"name": "conflict"}}
+
+		when("_, err := ToMap(v) is called", func(it bdd.It) {
+			_, err := ToMap(v)
+
+			it("should return an error", func(assert bdd.Assert) {
+				assert.Error(err)
+			})
+		})
+	})
+}
+
+// BenchmarkToMap measures the translation of a document with ToMap.
+func BenchmarkToMap(b *testing.B) {
+	opts := Options{OmitEmpty: true}
+
+	v := newSample()
+	v.When, v.Wait, v.Stamp = time.Time{}, 0, stamp{}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := ToMapWith(opts, v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkToMapRoundTrip measures the translation of a document
+// marshalling and unmarshalling it, as done before ToMap.
+func BenchmarkToMapRoundTrip(b *testing.B) {
+	opts := Options{OmitEmpty: true}
+
+	v := newSample()
+	v.When, v.Wait, v.Stamp = time.Time{}, 0, stamp{}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := roundTrip(opts, v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkDecodeRaw measures the decoding of a document found,
+// straight onto its struct.
+func BenchmarkDecodeRaw(b *testing.B) {
+	data, _ := bson.Marshal(newSample())
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var raw bson.Raw
+		_ = bson.Unmarshal(data, &raw)
+		if err := Unmarshal(raw.Data, &sample{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkDecodeInit measures the decoding of a document found onto a
+// map, translated to its struct afterwards, as done before decoding
+// straight onto it.
+func BenchmarkDecodeInit(b *testing.B) {
+	opts := Options{OmitEmpty: true}
+
+	data, _ := bson.Marshal(newSample())
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var result interface{}
+		_ = bson.Unmarshal(data, &result)
+		marshalled, err := MarshalWith(opts, result)
+		if err == nil {
+			err = UnmarshalWith(opts, marshalled, &sample{})
+		}
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
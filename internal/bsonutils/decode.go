@@ -40,15 +40,17 @@ import (
 )
 
 type decoder struct {
-	in      []byte
-	i       int
-	docType reflect.Type
+	in       []byte
+	i        int
+	docType  reflect.Type
+	keyCase  KeyCase
+	registry *Registry
 }
 
 var typeM = reflect.TypeOf(bson.M{})
 
 func newDecoder(in []byte) *decoder {
-	return &decoder{in, 0, typeM}
+	return &decoder{in, 0, typeM, LowerCase, nil}
 }
 
 // --------------------------------------------------------------------------
@@ -189,7 +191,7 @@ func (d *decoder) readDocTo(out reflect.Value) {
 			clearMap(out)
 		}
 	case reflect.Struct:
-		sinfo, err := getStructInfo(out.Type())
+		sinfo, err := getStructInfoWith(out.Type(), d.keyCase)
 		if err != nil {
 			panic(err)
 		}
@@ -236,7 +238,12 @@ func (d *decoder) readDocTo(out reflect.Value) {
 			e := reflect.New(elemType).Elem()
 			if d.readElemTo(e, kind) {
 				k := reflect.ValueOf(name)
-				if convertKey {
+				if dec := d.registry.decoder(keyType); dec != nil {
+					k = reflect.New(keyType).Elem()
+					if err := dec(rawString(name), k); err != nil {
+						panic(err)
+					}
+				} else if convertKey {
 					mapKeyType := out.Type().Key()
 					mapKeyKind := mapKeyType.Kind()
 
@@ -513,6 +520,37 @@ func (d *decoder) dropElem(kind byte) {
 	d.i += size
 }
 
+// readRegisteredTo decodes the element onto out with the decoder
+// registered for its type, or for the type it points to, reporting if
+// one was found.
+func (d *decoder) readRegisteredTo(out reflect.Value, kind byte) (good, found bool) {
+	outt := out.Type()
+
+	target, viaPtr := out, false
+	dec := d.registry.decoder(outt)
+	if dec == nil && outt.Kind() == reflect.Ptr {
+		if dec = d.registry.decoder(outt.Elem()); dec != nil {
+			target, viaPtr = reflect.New(outt.Elem()).Elem(), true
+		}
+	}
+
+	if found = dec != nil; found {
+		raw := d.readRaw(kind)
+		if viaPtr && kind == bson.ElementNil {
+			out.Set(reflect.Zero(outt))
+			good = true
+		} else if err := dec(raw, target); err == nil {
+			if viaPtr {
+				out.Set(target.Addr())
+			}
+			good = true
+		} else if _, ok := err.(*bson.TypeError); !ok {
+			panic(err)
+		}
+	}
+	return
+}
+
 // Attempt to decode an element from the document and put it into out.
 // If the types are not compatible, the returned ok value will be
 // false and out will be unchanged.
@@ -530,6 +568,12 @@ func (d *decoder) readElemTo(out reflect.Value, kind byte) (good bool) {
 		return true
 	}
 
+	if d.registry != nil {
+		if good, found := d.readRegisteredTo(out, kind); found {
+			return good
+		}
+	}
+
 	if kind == bson.ElementDocument {
 		// Delegate unmarshaling of documents.
 		outt := out.Type()
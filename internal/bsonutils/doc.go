@@ -9,5 +9,15 @@ I've created this package to implement my needed version of Marshal and
 Unmarshal functions: one that allows to set OmitEmpty tag as default.
 Since it's code exclusive for use on this package, it was put as a
 internal package.
+
+The defaults are received on each call, with MarshalWith, UnmarshalWith
+and ToMapWith, through Options: omitempty and minsize on all fields,
+the case of keys derived from field names, and nil slices as null.
+Options can also hold a Registry, with encoders and decoders of custom
+types consulted before the built-in kinds.
+
+Besides BSON, documents can be translated to and from MongoDB Extended
+JSON v2, on canonical or relaxed mode, with MarshalExtJSON and
+UnmarshalExtJSON.
 */
 package bsonutils
@@ -153,7 +153,8 @@ func getGetter(outt reflect.Type, out reflect.Value) bson.Getter {
 // Marshaling of the document value itself.
 
 type encoder struct {
-	out []byte
+	out  []byte
+	opts Options
 }
 
 func (e *encoder) addDoc(v reflect.Value) {
@@ -203,13 +204,21 @@ func (e *encoder) addDoc(v reflect.Value) {
 }
 
 func (e *encoder) addMap(v reflect.Value) {
+	enc := e.opts.Registry.encoder(v.Type().Key())
 	for _, k := range v.MapKeys() {
-		e.addElem(fmt.Sprint(k), v.MapIndex(k), false)
+		name := k.Interface()
+		if enc != nil {
+			var err error
+			if name, err = enc(k); err != nil {
+				panic(err)
+			}
+		}
+		e.addElem(fmt.Sprint(name), v.MapIndex(k), false)
 	}
 }
 
 func (e *encoder) addStruct(v reflect.Value) {
-	sinfo, err := getStructInfo(v.Type())
+	sinfo, err := getStructInfoWith(v.Type(), e.opts.KeyCase)
 	if err != nil {
 		panic(err)
 	}
@@ -243,10 +252,10 @@ func (e *encoder) addStruct(v reflect.Value) {
 
 			value = field
 		}
-		if (info.OmitEmpty || IsOmitEmptyAsDefault()) && isZero(value) {
+		if (info.OmitEmpty || e.opts.OmitEmpty || e.opts.OmitEmptyIDs && value.Type() == typeObjectId) && isZero(value) {
 			continue
 		}
-		e.addElem(info.Key, value, info.MinSize)
+		e.addElem(info.Key, value, info.MinSize || e.opts.MinSize)
 	}
 }
 
@@ -353,6 +362,15 @@ func (e *encoder) addElem(name string, v reflect.Value, minSize bool) {
 		return
 	}
 
+	if enc := e.opts.Registry.encoder(v.Type()); enc != nil {
+		encv, err := enc(v)
+		if err != nil {
+			panic(err)
+		}
+		e.addElem(name, reflect.ValueOf(encv), minSize)
+		return
+	}
+
 	if getter := getGetter(v.Type(), v); getter != nil {
 		getv, err := getter.GetBSON()
 		if err != nil {
@@ -457,6 +475,11 @@ func (e *encoder) addElem(name string, v reflect.Value, minSize bool) {
 		e.addDoc(v)
 
 	case reflect.Slice:
+		if v.IsNil() && e.opts.NilSliceAsNull {
+			e.addElemName(0x0A, name)
+			return
+		}
+
 		vt := v.Type()
 		et := vt.Elem()
 		if et.Kind() == reflect.Uint8 {
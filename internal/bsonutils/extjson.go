@@ -0,0 +1,686 @@
+// Copyright 2009 Dênnis Dantas de Sousa. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bsonutils
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// extTypes are the keys of objects wrapping, on Extended JSON, values
+// of BSON types without an equivalent on JSON. Objects using them must
+// be valid wrappers.
+var extTypes = map[string]bool{
+	"$oid":               true,
+	"$symbol":            true,
+	"$numberInt":         true,
+	"$numberLong":        true,
+	"$numberDouble":      true,
+	"$numberDecimal":     true,
+	"$binary":            true,
+	"$code":              true,
+	"$scope":             true,
+	"$timestamp":         true,
+	"$regularExpression": true,
+	"$dbPointer":         true,
+	"$date":              true,
+	"$minKey":            true,
+	"$maxKey":            true,
+	"$undefined":         true,
+}
+
+// MarshalExtJSON serializes the in value, a map or a struct, to MongoDB
+// Extended JSON v2, the same way Marshal serializes it to BSON. On
+// canonical mode, every value keeps its BSON type, using objects like
+// {"$numberLong": "1"} when JSON has no equivalent type. On relaxed
+// mode, numbers and dates use native JSON values when they don't lose
+// precision, being easier to read, but int64 values fitting on int32
+// are found as int32 when unmarshalled, and so are durations, that
+// need canonical mode to keep their unit.
+func MarshalExtJSON(in interface{}, canonical bool) (out []byte, err error) {
+	return MarshalExtJSONWith(defaultOptions(), in, canonical)
+}
+
+// MarshalExtJSONWith behaves the same way as MarshalExtJSON, using the
+// options received, like MarshalWith does.
+func MarshalExtJSONWith(opts Options, in interface{}, canonical bool) (out []byte, err error) {
+	var data []byte
+	if data, err = MarshalWith(opts, in); err == nil {
+		out, err = bsonToExtJSON(data, canonical)
+	}
+	return
+}
+
+// UnmarshalExtJSON deserializes the MongoDB Extended JSON v2 document
+// on data, on canonical or relaxed mode, onto the out value, the same
+// way Unmarshal deserializes BSON. Plain JSON numbers are found as
+// int32, int64 or double, the first one holding them without loss.
+func UnmarshalExtJSON(data []byte, out interface{}) (err error) {
+	return UnmarshalExtJSONWith(defaultOptions(), data, out)
+}
+
+// UnmarshalExtJSONWith behaves the same way as UnmarshalExtJSON, using
+// the options received, like UnmarshalWith does.
+func UnmarshalExtJSONWith(opts Options, data []byte, out interface{}) (err error) {
+	var in []byte
+	if in, err = extJSONToBSON(data); err == nil {
+		err = UnmarshalWith(opts, in, out)
+	}
+	return
+}
+
+// --------------------------------------------------------------------------
+// Translation of BSON to Extended JSON.
+
+// extJSONWriter writes the Extended JSON of the BSON document it reads.
+type extJSONWriter struct {
+	*decoder
+	out       bytes.Buffer
+	canonical bool
+}
+
+// bsonToExtJSON translates the BSON document on data to Extended JSON.
+func bsonToExtJSON(data []byte, canonical bool) (out []byte, err error) {
+	defer handleErr(&err)
+
+	w := &extJSONWriter{decoder: newDecoder(data), canonical: canonical}
+	w.writeDoc(false)
+	if w.i < len(w.in) {
+		corrupted()
+	}
+
+	out = w.out.Bytes()
+	return
+}
+
+// writeDoc writes the document read as an object, or as an array.
+func (w *extJSONWriter) writeDoc(array bool) {
+	end := int(w.readInt32())
+	end += w.i - 4
+	if end <= w.i || end > len(w.in) || w.in[end-1] != '\x00' {
+		corrupted()
+	}
+
+	open, close := byte('{'), byte('}')
+	if array {
+		open, close = '[', ']'
+	}
+
+	w.out.WriteByte(open)
+	for n := 0; w.in[w.i] != '\x00'; n++ {
+		kind := w.readByte()
+		name := w.readCStr()
+		if w.i >= end {
+			corrupted()
+		}
+
+		if n > 0 {
+			w.out.WriteByte(',')
+		}
+		if !array {
+			w.out.WriteString(quote(name))
+			w.out.WriteByte(':')
+		}
+		w.writeElem(kind)
+
+		if w.i >= end {
+			corrupted()
+		}
+	}
+	w.i++ // '\x00'
+	if w.i != end {
+		corrupted()
+	}
+	w.out.WriteByte(close)
+}
+
+// writeElem writes the element of the kind received.
+func (w *extJSONWriter) writeElem(kind byte) {
+	switch kind {
+	case 0x01:
+		f := w.readFloat64()
+		if w.canonical || math.IsInf(f, 0) || math.IsNaN(f) {
+			fmt.Fprintf(&w.out, `{"$numberDouble":"%s"}`, formatDouble(f))
+		} else {
+			w.out.WriteString(formatDouble(f))
+		}
+	case 0x02:
+		w.out.WriteString(quote(w.readStr()))
+	case 0x03:
+		w.writeDoc(false)
+	case 0x04:
+		w.writeDoc(true)
+	case 0x05:
+		b := w.readBinary()
+		fmt.Fprintf(&w.out, `{"$binary":{"base64":"%s","subType":"%02x"}}`, base64.StdEncoding.EncodeToString(b.Data), b.Kind)
+	case 0x06:
+		w.out.WriteString(`{"$undefined":true}`)
+	case 0x07:
+		fmt.Fprintf(&w.out, `{"$oid":"%x"}`, w.readBytes(12))
+	case 0x08:
+		w.out.WriteString(strconv.FormatBool(w.readBool()))
+	case 0x09:
+		w.writeDate(w.readInt64())
+	case 0x0A:
+		w.out.WriteString("null")
+	case 0x0B:
+		pattern := w.readCStr()
+		options := w.readCStr()
+		fmt.Fprintf(&w.out, `{"$regularExpression":{"pattern":%s,"options":%s}}`, quote(pattern), quote(options))
+	case 0x0C:
+		ns := w.readStr()
+		fmt.Fprintf(&w.out, `{"$dbPointer":{"$ref":%s,"$id":{"$oid":"%x"}}}`, quote(ns), w.readBytes(12))
+	case 0x0D:
+		fmt.Fprintf(&w.out, `{"$code":%s}`, quote(w.readStr()))
+	case 0x0E:
+		fmt.Fprintf(&w.out, `{"$symbol":%s}`, quote(w.readStr()))
+	case 0x0F:
+		start := w.i
+		end := start + int(w.readInt32())
+		fmt.Fprintf(&w.out, `{"$code":%s,"$scope":`, quote(w.readStr()))
+		w.writeDoc(false)
+		w.out.WriteByte('}')
+		if w.i != end {
+			corrupted()
+		}
+	case 0x10:
+		if i := w.readInt32(); w.canonical {
+			fmt.Fprintf(&w.out, `{"$numberInt":"%d"}`, i)
+		} else {
+			fmt.Fprintf(&w.out, "%d", i)
+		}
+	case 0x11:
+		ts := uint64(w.readInt64())
+		fmt.Fprintf(&w.out, `{"$timestamp":{"t":%d,"i":%d}}`, ts>>32, uint32(ts))
+	case 0x12:
+		if i := w.readInt64(); w.canonical {
+			fmt.Fprintf(&w.out, `{"$numberLong":"%d"}`, i)
+		} else {
+			fmt.Fprintf(&w.out, "%d", i)
+		}
+	case 0x13:
+		l := uint64(w.readInt64())
+		h := uint64(w.readInt64())
+		fmt.Fprintf(&w.out, `{"$numberDecimal":"%s"}`, formatDecimal(decimal128{h, l}))
+	case 0x7F:
+		w.out.WriteString(`{"$maxKey":1}`)
+	case 0xFF:
+		w.out.WriteString(`{"$minKey":1}`)
+	default:
+		panic(fmt.Sprintf("Unknown element kind (0x%02X)", kind))
+	}
+}
+
+// writeDate writes the date at ms milliseconds since the epoch. On
+// relaxed mode, dates between years 1970 and 9999 are written as
+// ISO-8601 strings.
+func (w *extJSONWriter) writeDate(ms int64) {
+	t := time.Unix(ms/1e3, ms%1e3*1e6).UTC()
+	if !w.canonical && t.Year() >= 1970 && t.Year() <= 9999 {
+		fmt.Fprintf(&w.out, `{"$date":"%s"}`, t.Format("2006-01-02T15:04:05.999Z07:00"))
+	} else {
+		fmt.Fprintf(&w.out, `{"$date":{"$numberLong":"%d"}}`, ms)
+	}
+}
+
+// formatDouble returns the representation of f on Extended JSON,
+// always with a decimal point or an exponent.
+func formatDouble(f float64) (s string) {
+	switch {
+	case math.IsInf(f, 1):
+		s = "Infinity"
+	case math.IsInf(f, -1):
+		s = "-Infinity"
+	case math.IsNaN(f):
+		s = "NaN"
+	default:
+		s = strconv.FormatFloat(f, 'G', -1, 64)
+		if !strings.ContainsAny(s, ".E") {
+			s += ".0"
+		}
+	}
+	return
+}
+
+// formatDecimal returns the representation of d on Extended JSON.
+func formatDecimal(d decimal128) (s string) {
+	switch s = d.String(); s {
+	case "Inf":
+		s = "Infinity"
+	case "-Inf":
+		s = "-Infinity"
+	}
+	return
+}
+
+// quote returns s as a JSON string, without escaping HTML characters.
+func quote(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// --------------------------------------------------------------------------
+// Translation of Extended JSON to BSON.
+
+// extDoc it's a JSON object, keeping the order of its keys. Its values
+// are extDoc, []interface{}, string, json.Number, bool or nil.
+type extDoc []extElem
+
+// extElem it's a key and value of an extDoc.
+type extElem struct {
+	key   string
+	value interface{}
+}
+
+// is reports if doc has exactly the keys received, on any order.
+func (doc extDoc) is(keys ...string) (ok bool) {
+	if ok = len(doc) == len(keys); ok {
+		for i := 0; i < len(keys) && ok; i++ {
+			_, ok = doc.get(keys[i])
+		}
+	}
+	return
+}
+
+// get returns the value of key on doc.
+func (doc extDoc) get(key string) (value interface{}, found bool) {
+	for i := 0; i < len(doc) && !found; i++ {
+		if found = doc[i].key == key; found {
+			value = doc[i].value
+		}
+	}
+	return
+}
+
+// extJSONToBSON translates the Extended JSON document on data to BSON.
+func extJSONToBSON(data []byte) (out []byte, err error) {
+	defer handleErr(&err)
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	doc, ok := readExtValue(dec, readToken(dec)).(extDoc)
+	if !ok {
+		panic("Extended JSON must hold a document")
+	}
+	if _, errEnd := dec.Token(); errEnd != io.EOF {
+		panic("Extended JSON must hold a single document")
+	}
+
+	e := &encoder{make([]byte, 0, initialBufferSize), Options{}}
+	e.addExtDoc(doc)
+	out = e.out
+	return
+}
+
+// readToken reads the next JSON token from dec.
+func readToken(dec *json.Decoder) (tok json.Token) {
+	var err error
+	if tok, err = dec.Token(); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		panic(err)
+	}
+	return
+}
+
+// readExtValue reads the JSON value starting on token tok from dec.
+func readExtValue(dec *json.Decoder, tok json.Token) (v interface{}) {
+	switch tok {
+	case json.Delim('{'):
+		doc := extDoc{}
+		for dec.More() {
+			key := readToken(dec).(string)
+			doc = append(doc, extElem{key, readExtValue(dec, readToken(dec))})
+		}
+		readToken(dec)
+		v = doc
+	case json.Delim('['):
+		array := []interface{}{}
+		for dec.More() {
+			array = append(array, readExtValue(dec, readToken(dec)))
+		}
+		readToken(dec)
+		v = array
+	default:
+		v = tok
+	}
+	return
+}
+
+// addExtDoc adds the BSON document of doc.
+func (e *encoder) addExtDoc(doc extDoc) {
+	start := e.reserveInt32()
+	for _, elem := range doc {
+		e.addExtElem(elem.key, elem.value)
+	}
+	e.addBytes(0)
+	e.setInt32(start, int32(len(e.out)-start))
+}
+
+// addExtElem adds the element name with the JSON value v.
+func (e *encoder) addExtElem(name string, v interface{}) {
+	switch v := v.(type) {
+	case nil:
+		e.addElemName(0x0A, name)
+	case bool:
+		e.addElemName(0x08, name)
+		if v {
+			e.addBytes(1)
+		} else {
+			e.addBytes(0)
+		}
+	case string:
+		e.addElemName(0x02, name)
+		e.addStr(v)
+	case json.Number:
+		e.addExtNumber(name, string(v))
+	case []interface{}:
+		e.addElemName(0x04, name)
+		start := e.reserveInt32()
+		for i := range v {
+			e.addExtElem(strconv.Itoa(i), v[i])
+		}
+		e.addBytes(0)
+		e.setInt32(start, int32(len(e.out)-start))
+	case extDoc:
+		e.addExtWrapped(name, v)
+	}
+}
+
+// addExtNumber adds the element name with the JSON number s, as the
+// first of int32, int64 or double holding it without loss.
+func (e *encoder) addExtNumber(name string, s string) {
+	i, errInt := strconv.ParseInt(s, 10, 64)
+	switch {
+	case strings.ContainsAny(s, ".eE") || errInt != nil:
+		e.addElemName(0x01, name)
+		e.addFloat64(parseExtDouble(s))
+	case i >= math.MinInt32 && i <= math.MaxInt32:
+		e.addElemName(0x10, name)
+		e.addInt32(int32(i))
+	default:
+		e.addElemName(0x12, name)
+		e.addInt64(i)
+	}
+}
+
+// addExtWrapped adds the element name with the JSON object doc, being
+// a wrapper of a BSON type, or a document.
+func (e *encoder) addExtWrapped(name string, doc extDoc) {
+	switch {
+	case doc.is("$oid"):
+		e.addElemName(0x07, name)
+		e.addBytes(parseExtObjectId(doc[0].value)...)
+	case doc.is("$symbol"):
+		e.addElemName(0x0E, name)
+		e.addStr(extString(doc[0].value, "$symbol"))
+	case doc.is("$numberInt"):
+		i, err := strconv.ParseInt(extString(doc[0].value, "$numberInt"), 10, 32)
+		if err != nil {
+			panic(invalidExt("$numberInt"))
+		}
+		e.addElemName(0x10, name)
+		e.addInt32(int32(i))
+	case doc.is("$numberLong"):
+		e.addElemName(0x12, name)
+		e.addInt64(parseExtLong(doc[0].value, "$numberLong"))
+	case doc.is("$numberDouble"):
+		e.addElemName(0x01, name)
+		e.addFloat64(parseExtDouble(extString(doc[0].value, "$numberDouble")))
+	case doc.is("$numberDecimal"):
+		d, err := parseDecimal128(extString(doc[0].value, "$numberDecimal"))
+		if err != nil {
+			panic(err)
+		}
+		e.addElemName(0x13, name)
+		e.addInt64(int64(d.l))
+		e.addInt64(int64(d.h))
+	case doc.is("$binary"):
+		fields := extFields(doc[0].value, "$binary", "base64", "subType")
+		e.addElemName(0x05, name)
+		e.addBinary(parseExtSubtype(fields[1]), parseExtBase64(fields[0]))
+	case doc.is("$binary", "$type"):
+		// Legacy format of Extended JSON v1.
+		data, _ := doc.get("$binary")
+		subtype, _ := doc.get("$type")
+		e.addElemName(0x05, name)
+		e.addBinary(parseExtSubtype(subtype), parseExtBase64(data))
+	case doc.is("$code"):
+		e.addElemName(0x0D, name)
+		e.addStr(extString(doc[0].value, "$code"))
+	case doc.is("$code", "$scope"):
+		code, _ := doc.get("$code")
+		scope, _ := doc.get("$scope")
+		e.addElemName(0x0F, name)
+		start := e.reserveInt32()
+		e.addStr(extString(code, "$code"))
+		e.addExtDoc(extObject(scope, "$scope"))
+		e.setInt32(start, int32(len(e.out)-start))
+	case doc.is("$timestamp"):
+		fields := extFields(doc[0].value, "$timestamp", "t", "i")
+		e.addElemName(0x11, name)
+		e.addInt64(int64(parseExtUint32(fields[0])<<32 | parseExtUint32(fields[1])))
+	case doc.is("$regularExpression"):
+		fields := extFields(doc[0].value, "$regularExpression", "pattern", "options")
+		e.addElemName(0x0B, name)
+		e.addCStr(extString(fields[0], "$regularExpression"))
+		e.addCStr(sortOptions(extString(fields[1], "$regularExpression")))
+	case doc.is("$regex", "$options") && isExtRegex(doc):
+		// Legacy format of Extended JSON v1, not being a query operator.
+		pattern, _ := doc.get("$regex")
+		options, _ := doc.get("$options")
+		e.addElemName(0x0B, name)
+		e.addCStr(pattern.(string))
+		e.addCStr(sortOptions(options.(string)))
+	case doc.is("$dbPointer"):
+		fields := extFields(doc[0].value, "$dbPointer", "$ref", "$id")
+		id := extObject(fields[1], "$dbPointer")
+		if !id.is("$oid") {
+			panic(invalidExt("$dbPointer"))
+		}
+		e.addElemName(0x0C, name)
+		e.addStr(extString(fields[0], "$dbPointer"))
+		e.addBytes(parseExtObjectId(id[0].value)...)
+	case doc.is("$date"):
+		e.addElemName(0x09, name)
+		e.addInt64(parseExtDate(doc[0].value))
+	case doc.is("$minKey"):
+		checkExtOne(doc[0].value, "$minKey")
+		e.addElemName(0xFF, name)
+	case doc.is("$maxKey"):
+		checkExtOne(doc[0].value, "$maxKey")
+		e.addElemName(0x7F, name)
+	case doc.is("$undefined"):
+		if doc[0].value != true {
+			panic(invalidExt("$undefined"))
+		}
+		e.addElemName(0x06, name)
+	default:
+		for _, elem := range doc {
+			if extTypes[elem.key] {
+				panic(invalidExt(elem.key))
+			}
+		}
+		e.addElemName(0x03, name)
+		e.addExtDoc(doc)
+	}
+}
+
+// invalidExt returns the error of an invalid wrapper of key.
+func invalidExt(key string) error {
+	return fmt.Errorf("invalid Extended JSON value on %s", key)
+}
+
+// extString returns v as a string, being the value on wrapper key.
+func extString(v interface{}, key string) (s string) {
+	var ok bool
+	if s, ok = v.(string); !ok {
+		panic(invalidExt(key))
+	}
+	return
+}
+
+// extObject returns v as an object, being the value on wrapper key.
+func extObject(v interface{}, key string) (doc extDoc) {
+	var ok bool
+	if doc, ok = v.(extDoc); !ok {
+		panic(invalidExt(key))
+	}
+	return
+}
+
+// extFields returns the values of v, an object with exactly the keys
+// received, being the value on wrapper key.
+func extFields(v interface{}, key string, keys ...string) (values []interface{}) {
+	doc := extObject(v, key)
+	if !doc.is(keys...) {
+		panic(invalidExt(key))
+	}
+
+	values = make([]interface{}, len(keys))
+	for i := range keys {
+		values[i], _ = doc.get(keys[i])
+	}
+	return
+}
+
+// isExtRegex reports if doc, with keys $regex and $options, is a
+// regular expression on the legacy format.
+func isExtRegex(doc extDoc) (ok bool) {
+	pattern, _ := doc.get("$regex")
+	options, _ := doc.get("$options")
+	_, ok = pattern.(string)
+	if ok {
+		_, ok = options.(string)
+	}
+	return
+}
+
+// checkExtOne checks v is the number 1, being the value on wrapper key.
+func checkExtOne(v interface{}, key string) {
+	if n, ok := v.(json.Number); !ok || n != "1" {
+		panic(invalidExt(key))
+	}
+}
+
+// parseExtObjectId returns the ObjectId bytes of v, an hexadecimal
+// string.
+func parseExtObjectId(v interface{}) (id []byte) {
+	var err error
+	if id, err = hex.DecodeString(extString(v, "$oid")); err != nil || len(id) != 12 {
+		panic(invalidExt("$oid"))
+	}
+	return
+}
+
+// parseExtLong returns the int64 of v, a decimal string, being the
+// value on wrapper key.
+func parseExtLong(v interface{}, key string) (i int64) {
+	var err error
+	if i, err = strconv.ParseInt(extString(v, key), 10, 64); err != nil {
+		panic(invalidExt(key))
+	}
+	return
+}
+
+// parseExtDouble returns the float64 of s, including the special
+// values of Extended JSON.
+func parseExtDouble(s string) (f float64) {
+	switch s {
+	case "Infinity":
+		f = math.Inf(1)
+	case "-Infinity":
+		f = math.Inf(-1)
+	case "NaN":
+		f = math.NaN()
+	default:
+		var err error
+		if f, err = strconv.ParseFloat(s, 64); err != nil {
+			panic(invalidExt("$numberDouble"))
+		}
+	}
+	return
+}
+
+// parseExtBase64 returns the bytes of v, a base64 string.
+func parseExtBase64(v interface{}) (data []byte) {
+	var err error
+	if data, err = base64.StdEncoding.DecodeString(extString(v, "$binary")); err != nil {
+		panic(invalidExt("$binary"))
+	}
+	return
+}
+
+// parseExtSubtype returns the binary subtype of v, an hexadecimal
+// string.
+func parseExtSubtype(v interface{}) (subtype byte) {
+	s := extString(v, "$binary")
+	if n, err := strconv.ParseUint(s, 16, 8); err == nil && len(s) <= 2 {
+		subtype = byte(n)
+	} else {
+		panic(invalidExt("$binary"))
+	}
+	return
+}
+
+// parseExtUint32 returns the uint64 of v, a number fitting on uint32.
+func parseExtUint32(v interface{}) (u uint64) {
+	n, ok := v.(json.Number)
+	if !ok {
+		panic(invalidExt("$timestamp"))
+	}
+
+	var err error
+	if u, err = strconv.ParseUint(string(n), 10, 32); err != nil {
+		panic(invalidExt("$timestamp"))
+	}
+	return
+}
+
+// parseExtDate returns the milliseconds since the epoch of v, an
+// ISO-8601 string, a $numberLong wrapper, or a number on the legacy
+// format.
+func parseExtDate(v interface{}) (ms int64) {
+	switch v := v.(type) {
+	case string:
+		t, err := time.Parse(time.RFC3339Nano, v)
+		if err != nil {
+			panic(invalidExt("$date"))
+		}
+		ms = t.Unix()*1e3 + int64(t.Nanosecond()/1e6)
+	case extDoc:
+		if !v.is("$numberLong") {
+			panic(invalidExt("$date"))
+		}
+		ms = parseExtLong(v[0].value, "$date")
+	case json.Number:
+		ms = parseExtLong(string(v), "$date")
+	default:
+		panic(invalidExt("$date"))
+	}
+	return
+}
+
+// sortOptions returns the options of a regular expression sorted, as
+// stored on BSON.
+func sortOptions(options string) string {
+	r := []byte(options)
+	sort.Slice(r, func(i, j int) bool { return r[i] < r[j] })
+	return string(r)
+}
@@ -0,0 +1,186 @@
+// +build !acceptance
+
+package bsonutils
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/ddspog/bdd"
+	"github.com/globalsign/mgo/bson"
+)
+
+// newTypes returns a document holding values of every BSON type.
+func newTypes() (d bson.D) {
+	dec, _ := parseDecimal128("-1.05E+3")
+	id := bson.ObjectIdHex("000070726f64756374316964")
+
+	d = bson.D{
+		{Name: "double", Value: 1.5},
+		{Name: "whole", Value: float64(-2)},
+		{Name: "inf", Value: math.Inf(-1)},
+		{Name: "string", Value: "<a> \"b\" ç"},
+		{Name: "doc", Value: bson.D{{Name: "a", Value: 1}}},
+		{Name: "array", Value: []interface{}{1, "b", nil}},
+		{Name: "binary", Value: bson.Binary{Kind: 0x00, Data: []byte("data")}},
+		{Name: "old", Value: bson.Binary{Kind: 0x02, Data: []byte("old")}},
+		{Name: "uuid", Value: bson.Binary{Kind: 0x04, Data: bytes.Repeat([]byte{7}, 16)}},
+		{Name: "undefined", Value: undefined{}},
+		{Name: "id", Value: id},
+		{Name: "bool", Value: true},
+		{Name: "date", Value: time.Date(2018, 5, 1, 10, 0, 0, 123000000, time.UTC)},
+		{Name: "old_date", Value: time.Date(1960, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{Name: "null", Value: nil},
+		{Name: "regex", Value: bson.RegEx{Pattern: "^a", Options: "im"}},
+		{Name: "pointer", Value: bson.DBPointer{Namespace: "db.c", Id: id}},
+		{Name: "code", Value: bson.JavaScript{Code: "f()"}},
+		{Name: "symbol", Value: bson.Symbol("s")},
+		{Name: "scope", Value: bson.JavaScript{Code: "g(x)", Scope: bson.D{{Name: "x", Value: 1}}}},
+		{Name: "int", Value: int32(-3)},
+		{Name: "timestamp", Value: bson.MongoTimestamp(5<<32 | 2)},
+		{Name: "long", Value: int64(1 << 40)},
+		{Name: "small_long", Value: int64(4)},
+		{Name: "decimal", Value: dec},
+		{Name: "min", Value: bson.MinKey},
+		{Name: "max", Value: bson.MaxKey},
+	}
+	return
+}
+
+// Feature Translate documents to Extended JSON
+// - As a developer,
+// - I want to marshal and unmarshal documents as Extended JSON,
+// - So that I can use them on APIs, fixtures and debugging.
+func Test_Translate_documents_to_Extended_JSON(t *testing.T) {
+	given, like, s := bdd.Sentences().All()
+
+	given(t, "a document with values of every BSON type", func(when bdd.When) {
+		d := newTypes()
+		data, _ := Marshal(d)
+
+		when("js, err := MarshalExtJSON(d, true) is called", func(it bdd.It) {
+			js, err := MarshalExtJSON(d, true)
+
+			back, errBack := extJSONToBSON(js)
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.NoError(err)
+				assert.NoError(errBack)
+			})
+			it("should write every value wrapped on canonical mode", func(assert bdd.Assert) {
+				assert.Contains(string(js), `"double":{"$numberDouble":"1.5"}`)
+				assert.Contains(string(js), `"whole":{"$numberDouble":"-2.0"}`)
+				assert.Contains(string(js), `"inf":{"$numberDouble":"-Infinity"}`)
+				assert.Contains(string(js), `"string":"<a> \"b\" ç"`)
+				assert.Contains(string(js), `"doc":{"a":{"$numberInt":"1"}}`)
+				assert.Contains(string(js), `"array":[{"$numberInt":"1"},"b",null]`)
+				assert.Contains(string(js), `"binary":{"$binary":{"base64":"ZGF0YQ==","subType":"00"}}`)
+				assert.Contains(string(js), `"old":{"$binary":{"base64":"b2xk","subType":"02"}}`)
+				assert.Contains(string(js), `"undefined":{"$undefined":true}`)
+				assert.Contains(string(js), `"id":{"$oid":"000070726f64756374316964"}`)
+				assert.Contains(string(js), `"date":{"$date":{"$numberLong":"1525168800123"}}`)
+				assert.Contains(string(js), `"regex":{"$regularExpression":{"pattern":"^a","options":"im"}}`)
+				assert.Contains(string(js), `"pointer":{"$dbPointer":{"$ref":"db.c","$id":{"$oid":"000070726f64756374316964"}}}`)
+				assert.Contains(string(js), `"code":{"$code":"f()"}`)
+				assert.Contains(string(js), `"symbol":{"$symbol":"s"}`)
+				assert.Contains(string(js), `"scope":{"$code":"g(x)","$scope":{"x":{"$numberInt":"1"}}}`)
+				assert.Contains(string(js), `"timestamp":{"$timestamp":{"t":5,"i":2}}`)
+				assert.Contains(string(js), `"long":{"$numberLong":"1099511627776"}`)
+				assert.Contains(string(js), `"decimal":{"$numberDecimal":"-1.05E+3"}`)
+				assert.Contains(string(js), `"min":{"$minKey":1},"max":{"$maxKey":1}`)
+			})
+			it("should translate back to the same BSON of the document", func(assert bdd.Assert) {
+				assert.Equal(data, back)
+			})
+		})
+
+		when("js, err := MarshalExtJSON(d, false) is called", func(it bdd.It) {
+			js, err := MarshalExtJSON(d, false)
+
+			back, errBack := extJSONToBSON(js)
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.NoError(err)
+				assert.NoError(errBack)
+			})
+			it("should write numbers and dates natively on relaxed mode", func(assert bdd.Assert) {
+				assert.Contains(string(js), `"double":1.5,"whole":-2.0,"inf":{"$numberDouble":"-Infinity"}`)
+				assert.Contains(string(js), `"doc":{"a":1}`)
+				assert.Contains(string(js), `"date":{"$date":"2018-05-01T10:00:00.123Z"}`)
+				assert.Contains(string(js), `"old_date":{"$date":{"$numberLong":"-315619200000"}}`)
+				assert.Contains(string(js), `"int":-3`)
+				assert.Contains(string(js), `"long":1099511627776,"small_long":4`)
+				assert.Contains(string(js), `"timestamp":{"$timestamp":{"t":5,"i":2}}`)
+			})
+			it("should translate back to the same BSON, except for small int64", func(assert bdd.Assert) {
+				expected := newTypes()
+				expected[23].Value = int32(4)
+				data, _ := Marshal(expected)
+				assert.Equal(data, back)
+			})
+		})
+	})
+
+	given(t, "a sample value marshalled to Extended JSON on canonical mode", func(when bdd.When) {
+		js, _ := MarshalExtJSON(newSample(), true)
+
+		when("UnmarshalExtJSON(js, &out) is called", func(it bdd.It) {
+			var out sample
+			err := UnmarshalExtJSON(js, &out)
+
+			var expected sample
+			data, _ := Marshal(newSample())
+			_ = Unmarshal(data, &expected)
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.NoError(err)
+			})
+			it("should return the value unmarshalled from BSON", func(assert bdd.Assert) {
+				assert.Equal(expected, out)
+			})
+		})
+	})
+
+	given(t, "the Extended JSON %[1]s on a legacy or relaxed format", func(when bdd.When, args ...interface{}) {
+		when("UnmarshalExtJSON(js, &out) is called", func(it bdd.It) {
+			var out bson.M
+			err := UnmarshalExtJSON([]byte(args[0].(string)), &out)
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.NoError(err)
+			})
+			it("should return %[2]v", func(assert bdd.Assert) {
+				assert.Equal(args[1].(bson.M), out)
+			})
+		})
+	}, like(
+		s(`{"a": {"$binary": "AQI=", "$type": "80"}}`, bson.M{"a": bson.Binary{Kind: 0x80, Data: []byte{1, 2}}}),
+		s(`{"a": {"$regex": "^a", "$options": "xi"}}`, bson.M{"a": bson.RegEx{Pattern: "^a", Options: "ix"}}),
+		s(`{"a": {"$regex": {"$oid": "000070726f64756374316964"}, "$options": "i"}}`,
+			bson.M{"a": bson.M{"$regex": bson.ObjectIdHex("000070726f64756374316964"), "$options": "i"}}),
+		s(`{"a": {"$date": 1000}, "b": {"$date": "1970-01-01T00:00:02+01:00"}}`,
+			bson.M{"a": time.Unix(1, 0).UTC(), "b": time.Unix(-3598, 0).UTC()}),
+		s(`{"a": 2147483648, "b": 1e2, "c": {"$in": [1]}}`,
+			bson.M{"a": int64(2147483648), "b": 100.0, "c": bson.M{"$in": []interface{}{1}}}),
+	))
+
+	given(t, "the invalid Extended JSON %[1]s", func(when bdd.When, args ...interface{}) {
+		when("UnmarshalExtJSON(js, &out) is called", func(it bdd.It) {
+			var out bson.M
+			err := UnmarshalExtJSON([]byte(args[0].(string)), &out)
+
+			it("should return an error", func(assert bdd.Assert) {
+				assert.Error(err)
+			})
+		})
+	}, like(
+		s(`[1]`), s(`{"a": 1} {}`), s(`{"a": 1`),
+		s(`{"a": {"$oid": "xyz"}}`), s(`{"a": {"$oid": "000070726f64756374316964", "b": 1}}`),
+		s(`{"a": {"$numberInt": "2147483648"}}`), s(`{"a": {"$numberLong": 1}}`),
+		s(`{"a": {"$numberDecimal": "1.2.3"}}`), s(`{"a": {"$binary": {"base64": "!", "subType": "00"}}}`),
+		s(`{"a": {"$timestamp": {"t": -1, "i": 0}}}`), s(`{"a": {"$date": "yesterday"}}`),
+		s(`{"a": {"$minKey": 2}}`), s(`{"a": {"$undefined": false}}`),
+	))
+}
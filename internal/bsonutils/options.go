@@ -0,0 +1,112 @@
+// Copyright 2009 Dênnis Dantas de Sousa. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bsonutils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// KeyCase it's the policy used to derive the keys of struct fields
+// without one defined on their tags.
+type KeyCase int
+
+const (
+	// LowerCase uses the field name lowercased, like mgo does:
+	// CreatedOn becomes createdon.
+	LowerCase KeyCase = iota
+	// SnakeCase uses the field name in snake case: CreatedOn becomes
+	// created_on.
+	SnakeCase
+	// CamelCase uses the field name in camel case: CreatedOn becomes
+	// createdOn.
+	CamelCase
+)
+
+// Options it's the set of options used when marshalling and
+// unmarshalling values, applied on a single call. They're used by
+// MarshalWith, UnmarshalWith and ToMapWith.
+type Options struct {
+	// OmitEmpty applies the omitempty flag on all struct fields.
+	OmitEmpty bool
+	// OmitEmptyIDs applies the omitempty flag on struct fields holding
+	// ObjectIds, that can't be marshalled when empty.
+	OmitEmptyIDs bool
+	// MinSize applies the minsize flag on all struct fields.
+	MinSize bool
+	// KeyCase defines the keys of struct fields without one defined on
+	// their tags. The same must be used to marshal and unmarshal values.
+	KeyCase KeyCase
+	// NilSliceAsNull marshals nil slices as null, instead of empty
+	// arrays.
+	NilSliceAsNull bool
+	// Registry holds the encoders and decoders of custom types,
+	// consulted before the built-in kinds.
+	Registry *Registry
+}
+
+// defaultOptions returns the options used by functions not receiving
+// them.
+func defaultOptions() (opts Options) {
+	opts = Options{
+		OmitEmpty: IsOmitEmptyAsDefault(),
+	}
+	return
+}
+
+// key returns the key derived from the field name, using the case c.
+func (c KeyCase) key(name string) (k string) {
+	switch c {
+	case SnakeCase:
+		k = snakeCase(name)
+	case CamelCase:
+		k = camelCase(name)
+	default:
+		k = strings.ToLower(name)
+	}
+	return
+}
+
+// snakeCase returns name in snake case, keeping acronyms together:
+// HTTPServer becomes http_server.
+func snakeCase(name string) (s string) {
+	runes := []rune(name)
+
+	var b strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || unicode.IsUpper(prev) && nextLower {
+				b.WriteRune('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+
+	s = b.String()
+	return
+}
+
+// camelCase returns name in camel case, lowering acronyms on its
+// beginning: IDValue becomes idValue.
+func camelCase(name string) (s string) {
+	runes := []rune(name)
+
+	n := 0
+	for n < len(runes) && unicode.IsUpper(runes[n]) {
+		n++
+	}
+	if n > 1 && n < len(runes) {
+		n--
+	}
+
+	for i := 0; i < n; i++ {
+		runes[i] = unicode.ToLower(runes[i])
+	}
+
+	s = string(runes)
+	return
+}
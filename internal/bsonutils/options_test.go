@@ -0,0 +1,127 @@
+// +build !acceptance
+
+package bsonutils
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/ddspog/bdd"
+	"github.com/globalsign/mgo/bson"
+)
+
+// record it's a type with fields without keys on tags.
+type record struct {
+	CreatedOn  int64
+	HTTPServer string
+	Tags       []string
+}
+
+// Feature Marshal values with options per call
+// - As a developer,
+// - I want to define marshalling defaults on each call,
+// - So that concurrent calls can't use the wrong defaults.
+func Test_Marshal_values_with_options_per_call(t *testing.T) {
+	given, like, s := bdd.Sentences().All()
+
+	given(t, "the value %+[1]v and the options %+[2]v", func(when bdd.When, args ...interface{}) {
+		opts := args[1].(Options)
+
+		when("data, err := MarshalWith(opts, value) is called", func(it bdd.It) {
+			data, err := MarshalWith(opts, args[0])
+
+			var out bson.M
+			errUnmarshal := bson.Unmarshal(data, &out)
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.NoError(err)
+				assert.NoError(errUnmarshal)
+			})
+			it("should store %[3]v", func(assert bdd.Assert) {
+				assert.Equal(args[2].(bson.M), out)
+			})
+		})
+
+		when("UnmarshalWith(opts, data, &r) is called with data from MarshalWith", func(it bdd.It) {
+			data, _ := MarshalWith(opts, args[0])
+
+			var r record
+			err := UnmarshalWith(opts, data, &r)
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.NoError(err)
+			})
+			it("should return the value marshalled", func(assert bdd.Assert) {
+				assert.Equal(args[0].(record).CreatedOn, r.CreatedOn)
+				assert.Equal(args[0].(record).HTTPServer, r.HTTPServer)
+			})
+		})
+	}, like(
+		s(record{CreatedOn: 1}, Options{},
+			bson.M{"createdon": int64(1), "httpserver": "", "tags": []interface{}{}}),
+		s(record{CreatedOn: 1}, Options{OmitEmpty: true},
+			bson.M{"createdon": int64(1)}),
+		s(record{CreatedOn: 1}, Options{OmitEmpty: true, MinSize: true},
+			bson.M{"createdon": 1}),
+		s(record{HTTPServer: "a"}, Options{KeyCase: SnakeCase, NilSliceAsNull: true},
+			bson.M{"created_on": int64(0), "http_server": "a", "tags": nil}),
+		s(record{HTTPServer: "a", Tags: []string{"b"}}, Options{KeyCase: CamelCase},
+			bson.M{"createdOn": int64(0), "httpServer": "a", "tags": []interface{}{"b"}}),
+	))
+
+	given(t, "a value with an empty ObjectId and the option OmitEmptyIDs", func(when bdd.When) {
+		opts := Options{OmitEmptyIDs: true}
+		v := struct {
+			ID   bson.ObjectId `bson:"_id"`
+			Name string        `bson:"name"`
+		}{}
+
+		when("data, err := MarshalWith(opts, v) and m, errMap := ToMapWith(opts, v) are called", func(it bdd.It) {
+			data, err := MarshalWith(opts, v)
+			m, errMap := ToMapWith(opts, v)
+
+			var out bson.M
+			_ = bson.Unmarshal(data, &out)
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.NoError(err)
+				assert.NoError(errMap)
+			})
+			it("should omit only the ObjectId", func(assert bdd.Assert) {
+				assert.Equal(bson.M{"name": ""}, out)
+				assert.Equal(bson.M{"name": ""}, m)
+			})
+		})
+	})
+
+	given(t, "20 goroutines marshalling a record with alternated OmitEmpty", func(when bdd.When) {
+		when("MarshalWith is called concurrently", func(it bdd.It) {
+			lens := make([]int, 20)
+
+			var wg sync.WaitGroup
+			for i := range lens {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+
+					data, _ := MarshalWith(Options{OmitEmpty: i%2 == 0}, record{CreatedOn: 1})
+
+					var out bson.M
+					_ = bson.Unmarshal(data, &out)
+					lens[i] = len(out)
+				}(i)
+			}
+			wg.Wait()
+
+			it("should use the options of each call", func(assert bdd.Assert) {
+				for i := range lens {
+					if i%2 == 0 {
+						assert.Equal(1, lens[i])
+					} else {
+						assert.Equal(3, lens[i])
+					}
+				}
+			})
+		})
+	})
+}
@@ -0,0 +1,102 @@
+// Copyright 2009 Dênnis Dantas de Sousa. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bsonutils
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// HasPath checks if the dotted path received leads to a field on type
+// t, following the bson keys of structs, including inlined ones.
+// Arrays are traversed like MongoDB does, accepting indexes and
+// positional operators. Maps, interfaces and structs with an inline map
+// accept any key.
+func HasPath(t reflect.Type, path string) (found bool) {
+	keys := strings.Split(path, ".")
+
+	found = true
+	for i := 0; i < len(keys) && found; i++ {
+		t = indirectType(t)
+
+		if isArrayType(t) {
+			t = indirectType(t.Elem())
+			if isArrayKey(keys[i]) {
+				continue
+			}
+		}
+
+		switch {
+		case isAnyDocType(t):
+			return
+		case t.Kind() == reflect.Struct:
+			var sinfo *structInfo
+			var err error
+			if sinfo, err = getStructInfo(t); err != nil {
+				found = false
+			} else if info, ok := sinfo.FieldsMap[keys[i]]; ok {
+				t = fieldType(t, info)
+			} else {
+				found = sinfo.InlineMap >= 0
+				return
+			}
+		default:
+			found = false
+		}
+	}
+
+	return
+}
+
+// indirectType returns the type pointed by t, following all pointers.
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// isArrayType checks if t is encoded as a BSON array. Binary data and
+// ordered documents aren't.
+func isArrayType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array:
+		elem := t.Elem()
+		return elem.Kind() != reflect.Uint8 && elem != typeDocElem && elem != typeRawDocElem
+	}
+	return false
+}
+
+// isArrayKey checks if key refers to elements of an array, being an
+// index or a positional operator.
+func isArrayKey(key string) bool {
+	if strings.HasPrefix(key, "$") {
+		return true
+	}
+	_, err := strconv.Atoi(key)
+	return err == nil
+}
+
+// isAnyDocType checks if t holds documents of any shape, like maps,
+// interfaces, ordered and raw documents.
+func isAnyDocType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Map, reflect.Interface:
+		return true
+	case reflect.Slice:
+		return t.Elem() == typeDocElem || t.Elem() == typeRawDocElem
+	}
+	return t == typeRaw
+}
+
+// fieldType returns the type of the field described by info on struct
+// type t, following inlined structs.
+func fieldType(t reflect.Type, info fieldInfo) reflect.Type {
+	if info.Inline != nil {
+		return t.FieldByIndex(info.Inline).Type
+	}
+	return t.Field(info.Num).Type
+}
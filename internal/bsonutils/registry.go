@@ -0,0 +1,160 @@
+// Copyright 2009 Dênnis Dantas de Sousa. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package bsonutils
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+// EncodeFunc it's a function translating the value v, of a registered
+// type, to the value stored on its place. It can return any value the
+// encoder handles, and nil to store null.
+type EncodeFunc func(v reflect.Value) (out interface{}, err error)
+
+// DecodeFunc it's a function decoding the raw value stored onto out, a
+// settable value of a registered type. Returning a *bson.TypeError
+// leaves out untouched, as Setters do, while other errors abort the
+// unmarshalling.
+type DecodeFunc func(raw bson.Raw, out reflect.Value) (err error)
+
+// codec it's the pair of functions registered for a type.
+type codec struct {
+	encode EncodeFunc
+	decode DecodeFunc
+}
+
+// ifaceCodec it's the codec registered for an interface.
+type ifaceCodec struct {
+	t reflect.Type
+	codec
+}
+
+// lookupKey it's the key of lookups cached by a Registry.
+type lookupKey struct {
+	t      reflect.Type
+	decode bool
+}
+
+// Registry it's a set of encoders and decoders of custom types,
+// consulted before the built-in kinds when marshalling and
+// unmarshalling with the options holding it. This includes struct
+// fields, map values and keys, and slice elements.
+type Registry struct {
+	mu         sync.RWMutex
+	types      map[reflect.Type]codec
+	interfaces []ifaceCodec
+	lookups    map[lookupKey]*codec
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() (r *Registry) {
+	r = &Registry{
+		types:   make(map[reflect.Type]codec),
+		lookups: make(map[lookupKey]*codec),
+	}
+	return
+}
+
+// RegisterType registers the functions encoding and decoding values of
+// type t, replacing the ones registered before. Any of them can be nil,
+// to keep the built-in behavior on that direction. Pointers to t are
+// handled by the functions registered for t.
+func (r *Registry) RegisterType(t reflect.Type, enc EncodeFunc, dec DecodeFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.types[t] = codec{enc, dec}
+	r.lookups = make(map[lookupKey]*codec)
+}
+
+// RegisterInterface registers the functions encoding and decoding
+// values of types implementing the interface t. Values are encoded
+// when their dynamic type implements t, and decoded when their type or
+// a pointer to it implements t, including fields declared as t. Types
+// registered with RegisterType, and interfaces registered first, take
+// precedence. It panics if t isn't an interface.
+func (r *Registry) RegisterInterface(t reflect.Type, enc EncodeFunc, dec DecodeFunc) {
+	if t.Kind() != reflect.Interface {
+		panic("RegisterInterface needs an interface type. Got: " + t.String())
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.interfaces = append(r.interfaces, ifaceCodec{t, codec{enc, dec}})
+	r.lookups = make(map[lookupKey]*codec)
+}
+
+// encoder returns the function registered to encode values of type t,
+// or nil if there's none. Interfaces have none, since the values they
+// hold are encoded instead. It can be called on a nil Registry.
+func (r *Registry) encoder(t reflect.Type) (enc EncodeFunc) {
+	if r != nil && t.Kind() != reflect.Interface {
+		if c := r.lookup(lookupKey{t, false}); c != nil {
+			enc = c.encode
+		}
+	}
+	return
+}
+
+// decoder returns the function registered to decode values of type t,
+// or nil if there's none. It can be called on a nil Registry.
+func (r *Registry) decoder(t reflect.Type) (dec DecodeFunc) {
+	if r != nil {
+		if c := r.lookup(lookupKey{t, true}); c != nil {
+			dec = c.decode
+		}
+	}
+	return
+}
+
+// lookup returns the codec matching the key k, caching the result.
+func (r *Registry) lookup(k lookupKey) (c *codec) {
+	r.mu.RLock()
+	c, found := r.lookups[k]
+	r.mu.RUnlock()
+
+	if !found {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		c = r.match(k)
+		r.lookups[k] = c
+	}
+	return
+}
+
+// match finds the codec matching the key k, with a function defined
+// on the direction needed.
+func (r *Registry) match(k lookupKey) (c *codec) {
+	defined := func(found codec) bool {
+		return k.decode && found.decode != nil || !k.decode && found.encode != nil
+	}
+
+	if found, ok := r.types[k.t]; ok && defined(found) {
+		c = &found
+	} else {
+		for _, i := range r.interfaces {
+			implements := k.t.Implements(i.t) || k.decode && k.t.Kind() != reflect.Ptr && reflect.PtrTo(k.t).Implements(i.t)
+			if implements && defined(i.codec) {
+				found := i.codec
+				c = &found
+				break
+			}
+		}
+	}
+	return
+}
+
+// rawString returns s as a raw BSON string, used to decode map keys.
+func rawString(s string) (raw bson.Raw) {
+	e := &encoder{}
+	e.addStr(s)
+	raw = bson.Raw{Kind: bson.ElementString, Data: e.out}
+	return
+}
@@ -0,0 +1,210 @@
+// +build !acceptance
+
+package bsonutils
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/ddspog/bdd"
+	"github.com/globalsign/mgo/bson"
+)
+
+// money it's a type stored as a string, with a codec registered.
+type money struct {
+	Cents int64
+}
+
+// labeled it's an interface of types stored as their labels.
+type labeled interface {
+	Label() string
+}
+
+// color it's a type stored as its label, through labeled.
+type color int
+
+// colors are the labels of each color.
+var colors = []string{"red", "green"}
+
+// Label returns the label of color.
+func (c color) Label() string {
+	return colors[c]
+}
+
+// priced it's a document declaring fields of registered types.
+type priced struct {
+	Price   money           `bson:"price"`
+	Prices  []money         `bson:"prices"`
+	Ptr     *money          `bson:"ptr"`
+	Nil     *money          `bson:"nil"`
+	ByColor map[color]money `bson:"by_color"`
+	Color   color           `bson:"color"`
+	Label   labeled         `bson:"label"`
+}
+
+// newRegistry returns a Registry with codecs for money and labeled.
+func newRegistry() (r *Registry) {
+	r = NewRegistry()
+	r.RegisterType(reflect.TypeOf(money{}), func(v reflect.Value) (interface{}, error) {
+		c := v.Interface().(money).Cents
+		return fmt.Sprintf("%d.%02d", c/100, c%100), nil
+	}, func(raw bson.Raw, out reflect.Value) (err error) {
+		var s string
+		var units, cents int64
+		if err = raw.Unmarshal(&s); err == nil {
+			if _, err = fmt.Sscanf(s, "%d.%d", &units, &cents); err == nil {
+				out.Set(reflect.ValueOf(money{units*100 + cents}))
+			}
+		}
+		return
+	})
+	r.RegisterInterface(reflect.TypeOf((*labeled)(nil)).Elem(), func(v reflect.Value) (interface{}, error) {
+		return v.Interface().(labeled).Label(), nil
+	}, func(raw bson.Raw, out reflect.Value) (err error) {
+		var s string
+		if err = raw.Unmarshal(&s); err == nil {
+			err = &bson.TypeError{Type: out.Type(), Kind: raw.Kind}
+			for i := range colors {
+				if colors[i] == s {
+					out.Set(reflect.ValueOf(color(i)))
+					err = nil
+				}
+			}
+		}
+		return
+	})
+	return
+}
+
+// Feature Marshal custom types with a Registry
+// - As a developer,
+// - I want to register encoders and decoders of my own types,
+// - So that they're stored the way I need, wherever they're used.
+func Test_Marshal_custom_types_with_a_Registry(t *testing.T) {
+	given := bdd.Sentences().Given()
+
+	given(t, "a priced value and a Registry with codecs for money and labeled", func(when bdd.When) {
+		v := priced{
+			Price:   money{1234},
+			Prices:  []money{{100}, {5}},
+			Ptr:     &money{5},
+			ByColor: map[color]money{1: {200}},
+			Color:   0,
+			Label:   color(1),
+		}
+		opts := Options{Registry: newRegistry()}
+
+		when("data, err := MarshalWith(opts, v) is called", func(it bdd.It) {
+			data, err := MarshalWith(opts, v)
+
+			var out bson.M
+			errUnmarshal := bson.Unmarshal(data, &out)
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.NoError(err)
+				assert.NoError(errUnmarshal)
+			})
+			it("should store the values returned by the encoders", func(assert bdd.Assert) {
+				assert.Equal(bson.M{
+					"price":    "12.34",
+					"prices":   []interface{}{"1.00", "0.05"},
+					"ptr":      "0.05",
+					"nil":      nil,
+					"by_color": bson.M{"green": "2.00"},
+					"color":    "red",
+					"label":    "green",
+				}, out)
+			})
+		})
+
+		when("UnmarshalWith(opts, data, &r) is called with data from MarshalWith", func(it bdd.It) {
+			data, _ := MarshalWith(opts, v)
+
+			var r priced
+			err := UnmarshalWith(opts, data, &r)
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.NoError(err)
+			})
+			it("should return the value marshalled", func(assert bdd.Assert) {
+				assert.Equal(v, r)
+			})
+		})
+
+		when("out, err := ToMapWith(opts, v) is called", func(it bdd.It) {
+			out, err := ToMapWith(opts, v)
+			expected, _ := roundTrip(opts, v)
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.NoError(err)
+			})
+			it("should return the map of a round trip", func(assert bdd.Assert) {
+				assert.Equal(expected, out)
+			})
+		})
+
+		when("data, err := MarshalWith(Options{}, v) is called, without the Registry", func(it bdd.It) {
+			data, err := MarshalWith(Options{}, v)
+
+			var out bson.M
+			_ = bson.Unmarshal(data, &out)
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.NoError(err)
+			})
+			it("should store the values with the built-in kinds", func(assert bdd.Assert) {
+				assert.Equal(bson.M{"cents": int64(1234)}, out["price"])
+				assert.Equal(0, out["color"])
+			})
+		})
+	})
+
+	given(t, "a document with an unknown label and a Registry with codecs for labeled", func(when bdd.When) {
+		opts := Options{Registry: newRegistry()}
+		data, _ := bson.Marshal(bson.M{"color": "blue", "price": "1.00"})
+
+		when("UnmarshalWith(opts, data, &r) is called", func(it bdd.It) {
+			var r priced
+			err := UnmarshalWith(opts, data, &r)
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.NoError(err)
+			})
+			it("should skip the field on type errors", func(assert bdd.Assert) {
+				assert.Equal(color(0), r.Color)
+				assert.Equal(money{100}, r.Price)
+			})
+		})
+	})
+
+	given(t, "a Registry with a decoder for money returning errors", func(when bdd.When) {
+		r := NewRegistry()
+		r.RegisterType(reflect.TypeOf(money{}), nil, func(bson.Raw, reflect.Value) error {
+			return errors.New("invalid money")
+		})
+		data, _ := bson.Marshal(bson.M{"price": "1.00"})
+
+		when("UnmarshalWith(opts, data, &r) is called", func(it bdd.It) {
+			err := UnmarshalWith(Options{Registry: r}, data, &priced{})
+
+			it("should return the error of the decoder", func(assert bdd.Assert) {
+				assert.Error(err)
+			})
+		})
+	})
+
+	given(t, "a Registry", func(when bdd.When) {
+		r := NewRegistry()
+
+		when("r.RegisterInterface is called with a struct type", func(it bdd.It) {
+			it("should panic", func(assert bdd.Assert) {
+				defer func() {
+					assert.NotNil(recover())
+				}()
+				r.RegisterInterface(reflect.TypeOf(money{}), nil, nil)
+			})
+		})
+	})
+}
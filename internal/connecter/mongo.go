@@ -4,9 +4,11 @@ package connecter
 // storing the Session, allowing easy access to Database object.
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"sync"
+	"time"
 
 	"github.com/globalsign/mgo"
 )
@@ -21,10 +23,17 @@ type MongoConnecter interface {
 	Session() *mgo.Session
 }
 
+// ContextConnecter it's an optional interface of MongoConnecter,
+// implemented by connecters able to connect stopping when a context is
+// done.
+type ContextConnecter interface {
+	ConnectCtx(ctx context.Context) error
+}
+
 // Mongo is a MongoConnecter that functions with a real MongoDB
 // connection.
 type Mongo struct {
-	once    sync.Once
+	mu      sync.RWMutex
 	session *mgo.Session
 	mongo   *mgo.DialInfo
 }
@@ -40,42 +49,117 @@ func New() (m MongoConnecter) {
 // Connect to MongoDB of server.
 // It tries to connect with MONGODB_URL, but without defining this
 // environment variable, tris to connect with default URL.
+// Once connected, it returns without dialing again, until Disconnect
+// is called. A failed attempt keeps no state, so calling it again
+// retries to connect.
 func (m *Mongo) Connect() (err error) {
-	m.once.Do(func() {
-		// Parse adequate MongoDB URI.
-		u := m.mongoURI()
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-		// Capture Session and Mongo objects using URI.
+	if m.session == nil {
+		var s *mgo.Session
 		var d *mgo.DialInfo
-		d, err = parseURL(u)
-		if err != nil {
-			err = fmt.Errorf("problem parsing Mongo URI uri=%[1]s err='%[2]v'", u, err.Error())
-			return
+		if s, d, err = m.dial(0); err == nil {
+			m.session, m.mongo = s, d
 		}
-		var s *mgo.Session
-		s, err = dial(u)
+	}
+
+	return
+}
+
+// ConnectCtx connects like Connect, stopping when ctx is done. The
+// deadline of ctx is used as timeout to dial the server. The session
+// dialed is only kept if ctx isn't done before dialing ends, being
+// closed on background otherwise, without touching the connection.
+func (m *Mongo) ConnectCtx(ctx context.Context) (err error) {
+	if err = ctx.Err(); err == nil && m.Session() == nil {
+		var timeout time.Duration
+		if deadline, ok := ctx.Deadline(); ok {
+			timeout = time.Until(deadline)
+		}
+
+		type result struct {
+			session *mgo.Session
+			mongo   *mgo.DialInfo
+			err     error
+		}
+
+		done := make(chan result, 1)
+		go func() {
+			s, d, err := m.dial(timeout)
+			done <- result{s, d, err}
+		}()
+
+		select {
+		case r := <-done:
+			if err = r.err; err == nil {
+				m.keep(r.session, r.mongo)
+			}
+		case <-ctx.Done():
+			err = ctx.Err()
+
+			go func() {
+				if r := <-done; r.err == nil {
+					r.session.Close()
+				}
+			}()
+		}
+	}
+
+	return
+}
+
+// dial connects to the MongoDB of server, returning the session and
+// the information used. A timeout greater than zero limits the time to
+// dial. It doesn't change the connection of Mongo.
+func (m *Mongo) dial(timeout time.Duration) (s *mgo.Session, d *mgo.DialInfo, err error) {
+	// Parse adequate MongoDB URI.
+	u := m.mongoURI()
+
+	// Capture Session and Mongo objects using URI.
+	if d, err = parseURL(u); err != nil {
+		err = fmt.Errorf("problem parsing Mongo URI uri=%[1]s err='%[2]v'", u, err.Error())
+	} else {
+		if timeout > 0 {
+			d.Timeout = timeout
+			s, err = dialWithInfo(d)
+		} else {
+			s, err = dial(u)
+		}
+
 		if err != nil {
 			err = fmt.Errorf("problem dialing Mongo URI uri=%[1]s err='%[2]v'", u, err.Error())
-			return
+		} else {
+			// No errors showing, save objects.
+			s.SetSafe(&mgo.Safe{})
+			//log.Printf("debug: - Connected to MongoDB URI. uri=%s", u)
 		}
+	}
 
-		// No errors showing, save objects.
-		s.SetSafe(&mgo.Safe{})
-		//log.Printf("debug: - Connected to MongoDB URI. uri=%s", u)
-
-		m.session = s
-		m.mongo = d
-	})
 	return
 }
 
+// keep stores the session s and information d dialed as the connection
+// of Mongo. If another connection was made meanwhile, s is closed.
+func (m *Mongo) keep(s *mgo.Session, d *mgo.DialInfo) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.session == nil {
+		m.session, m.mongo = s, d
+	} else {
+		s.Close()
+	}
+}
+
 // Disconnect undo the connection made. Preparing package for a new
 // connection.
 func (m *Mongo) Disconnect() {
-	m.once = *new(sync.Once)
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
-	if m.Session() != nil {
-		m.Session().Close()
+	if m.session != nil {
+		m.session.Close()
 	}
 
 	m.session = nil
@@ -86,11 +170,17 @@ func (m *Mongo) Disconnect() {
 // Databaser object to be consumed in f function. Closes session after
 // consume of Databaser object.
 func (m *Mongo) ConsumeDatabaseOnSession(f func(*mgo.Database)) {
-	if s := m.Session(); s != nil {
-		s := s.Clone()
+	m.mu.RLock()
+	s, d := m.session, m.mongo
+	if s != nil {
+		s = s.Clone()
+	}
+	m.mu.RUnlock()
+
+	if s != nil {
 		defer s.Close()
 
-		f(s.DB(m.mongo.Database))
+		f(s.DB(d.Database))
 	} else {
 		f(nil)
 	}
@@ -98,7 +188,9 @@ func (m *Mongo) ConsumeDatabaseOnSession(f func(*mgo.Database)) {
 
 // Session return connected mongo session.
 func (m *Mongo) Session() (s *mgo.Session) {
+	m.mu.RLock()
 	s = m.session
+	m.mu.RUnlock()
 	return
 }
 
@@ -113,6 +205,9 @@ var (
 	parseURL = mgo.ParseURL
 	// dial returns mongo session after connecting.
 	dial = mgo.Dial
+	// dialWithInfo returns mongo session after connecting with the
+	// information received.
+	dialWithInfo = mgo.DialWithInfo
 )
 
 // mongoURI load the selected MongoDB database url, or default.
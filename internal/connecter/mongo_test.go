@@ -3,8 +3,10 @@
 package connecter
 
 import (
+	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/ddspog/bdd"
 	"github.com/globalsign/mgo"
@@ -110,9 +112,127 @@ func Test_Mongo_dials_connection(t *testing.T) {
 	})
 }
 
+// Feature Mongo retries connecting after failures
+// - As a developer,
+// - I want to be able to connect again after a failed connection,
+// - So that a temporary failure doesn't leave Mongo disconnected.
+func Test_Mongo_retries_connecting_after_failures(t *testing.T) {
+	given := bdd.Sentences().Given()
+
+	given(t, "a new test MongoConnecter m, and dial returning error.New('any reason') only once", func(when bdd.When) {
+		mc := New()
+
+		dial = func(u string) (s *mgo.Session, err error) {
+			resetUtils()
+			err = errors.New("any reason")
+			return
+		}
+		defer resetUtils()
+
+		errFirst := mc.Connect()
+		errSecond := mc.Connect()
+		defer mc.Disconnect()
+
+		when("mc.Connect() is called twice", func(it bdd.It) {
+			it("should return an error only on first call", func(assert bdd.Assert) {
+				assert.Error(errFirst)
+				assert.Nil(errSecond)
+			})
+			it("should have a session after second call", func(assert bdd.Assert) {
+				assert.NotNil(mc.Session())
+			})
+		})
+	})
+}
+
+// Feature Mongo connects with context
+// - As a developer,
+// - I want to be able to use Mongo to connect respecting a context,
+// - So that cancellation and deadlines stop the connection.
+func Test_Mongo_connects_with_context(t *testing.T) {
+	given := bdd.Sentences().Given()
+
+	given(t, "a new test MongoConnecter m, and a cancelled context ctx", func(when bdd.When) {
+		mc := New().(*Mongo)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := mc.ConnectCtx(ctx)
+		defer mc.Disconnect()
+
+		when("err := mc.ConnectCtx(ctx) is called", func(it bdd.It) {
+			it("should return context.Canceled", func(assert bdd.Assert) {
+				assert.Equal(context.Canceled, err)
+			})
+			it("shouldn't have a session", func(assert bdd.Assert) {
+				assert.Nil(mc.Session())
+			})
+		})
+	})
+
+	given(t, "a new test MongoConnecter m, a context ctx with deadline, and dialWithInfo returning error.New('any reason')", func(when bdd.When) {
+		mc := New().(*Mongo)
+
+		var timeout time.Duration
+		dialWithInfo = func(info *mgo.DialInfo) (s *mgo.Session, err error) {
+			timeout = info.Timeout
+			err = errors.New("any reason")
+			return
+		}
+		defer resetUtils()
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+
+		err := mc.ConnectCtx(ctx)
+		defer mc.Disconnect()
+
+		when("err := mc.ConnectCtx(ctx) is called", func(it bdd.It) {
+			it("should return an error", func(assert bdd.Assert) {
+				assert.Error(err)
+			})
+			it("should dial with the time left on ctx as timeout", func(assert bdd.Assert) {
+				assert.True(timeout > 0 && timeout <= time.Minute)
+			})
+		})
+	})
+
+	given(t, "a new test MongoConnecter m, a context ctx expiring while dialWithInfo runs", func(when bdd.When) {
+		mc := New().(*Mongo)
+
+		started, release := make(chan bool, 1), make(chan bool)
+		dialWithInfo = func(info *mgo.DialInfo) (s *mgo.Session, err error) {
+			started <- true
+			<-release
+			err = errors.New("any reason")
+			return
+		}
+		defer resetUtils()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		err := mc.ConnectCtx(ctx)
+		<-started
+		close(release)
+		defer mc.Disconnect()
+
+		when("err := mc.ConnectCtx(ctx) is called", func(it bdd.It) {
+			it("should return context.DeadlineExceeded", func(assert bdd.Assert) {
+				assert.Equal(context.DeadlineExceeded, err)
+			})
+			it("shouldn't have a session", func(assert bdd.Assert) {
+				assert.Nil(mc.Session())
+			})
+		})
+	})
+}
+
 // resetUtils reset the functions defined to the initial purpose.
 // Making mocking really simple.
 func resetUtils() {
 	parseURL = mgo.ParseURL
 	dial = mgo.Dial
+	dialWithInfo = mgo.DialWithInfo
 }
@@ -1,6 +1,7 @@
 package connecter
 
 import (
+	"context"
 	"errors"
 	"io/ioutil"
 	"os"
@@ -80,6 +81,16 @@ func (m *TestMongo) Connect() (err error) {
 	return
 }
 
+// ConnectCtx connects like Connect, if ctx isn't done yet. Starting
+// the temp database can't be interrupted.
+func (m *TestMongo) ConnectCtx(ctx context.Context) (err error) {
+	if err = ctx.Err(); err == nil {
+		err = m.Connect()
+	}
+
+	return
+}
+
 // insertFixtures init the database with some documents, defined on the
 // constructor as fixtures.
 func (m *TestMongo) insertFixtures() (err error) {
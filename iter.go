@@ -0,0 +1,119 @@
+package mongo
+
+import (
+	"errors"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+)
+
+// ErrStopIteration it's an error to be returned by the function
+// consumed on Each, to stop the iteration without returning errors.
+var ErrStopIteration = errors.New("stop iteration")
+
+// Iter it's an iterator through documents found by a Handle. It
+// decodes one document at a time, avoiding loading all of them at
+// once on memory.
+type Iter struct {
+	handle     *Handle
+	iter       *mgo.Iter
+	projection M
+	err        error
+	closed     bool
+}
+
+// Iter search for all documents matching the document data on
+// collection connected to Handle, returning an iterator through them.
+// Accepts options to alter result. The iterator must be closed after
+// use, which also closes the Handle if Safely was called.
+func (h *Handle) Iter(opts ...QueryOptions) (it *Iter) {
+	it = &Iter{
+		handle:     h,
+		projection: projection(opts...),
+	}
+
+	if it.err = h.InternalErr; it.err == nil {
+		var mapped M
+		if mapped, it.err = h.mapped(); it.err == nil {
+			var qry *mgo.Query
+			if qry, it.err = h.query(mapped, opts...); it.err == nil {
+				it.iter = qry.Iter()
+			}
+		}
+	}
+
+	return
+}
+
+// Each search for all documents matching the document data on
+// collection connected to Handle, and consumes them one at a time with
+// function f. The iteration stops on first error returned by f, which
+// is returned by Each, unless it's ErrStopIteration.
+func (h *Handle) Each(f func(Documenter) error, opts ...QueryOptions) (err error) {
+	it := h.Iter(opts...)
+
+	for doc, ok := it.Next(); ok; doc, ok = it.Next() {
+		if err = f(doc); err != nil {
+			break
+		}
+	}
+
+	if errClose := it.Close(); err == nil {
+		err = errClose
+	} else if errors.Is(err, ErrStopIteration) {
+		err = nil
+	}
+
+	return
+}
+
+// Next decodes the next document found onto a new Documenter of the
+// same type of the Handle document. Returns false when there are no
+// more documents, or an error happened, closing the iterator.
+func (it *Iter) Next() (doc Documenter, ok bool) {
+	if it.err == nil && !it.closed {
+		var result bson.Raw
+		if ok = it.iter.Next(&result); ok {
+			doc = it.handle.Document().New()
+			if it.err = decode(result, doc); it.err != nil {
+				doc, ok = nil, false
+			} else {
+				setProjection(doc, it.projection)
+			}
+		}
+	}
+
+	if !ok {
+		it.Close()
+	}
+
+	return
+}
+
+// Err returns the error found during the iteration, if any.
+func (it *Iter) Err() (err error) {
+	err = it.err
+	return
+}
+
+// Close ends the iteration, releasing the cursor on database. Closes
+// the Handle if Safely was called. Returns the error found during the
+// iteration, if any.
+func (it *Iter) Close() (err error) {
+	if !it.closed {
+		it.closed = true
+
+		if it.iter != nil {
+			if errIter := it.iter.Close(); it.err == nil {
+				it.err = errIter
+			}
+		}
+
+		wrapErr(&it.err)
+
+		it.handle.ifSafelyClose()
+	}
+
+	err = it.err
+	return
+}
@@ -0,0 +1,219 @@
+package mongo
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+)
+
+var (
+	// ErrInvalidToken it's an error received when a continuation token
+	// can't be decoded, or doesn't match the sort used on query.
+	ErrInvalidToken = errors.New("invalid continuation token")
+	// ErrMissingSortKey it's an error received when the last document
+	// of a page lacks a sort field, or has it null, so no continuation
+	// token can be created.
+	ErrMissingSortKey = errors.New("sort field missing on document")
+)
+
+// keysetToken it's the content of a continuation token, storing the
+// sort keys used on query and the values of last document returned.
+type keysetToken struct {
+	Keys   []string      `bson:"k"`
+	Values []interface{} `bson:"v"`
+}
+
+// FindAfter search for a page of documents matching the document data
+// on collection connected to Handle, resuming after the position
+// encoded on token. An empty token starts from the first document.
+//
+// The documents are ordered by the Sort option, using _id to break
+// ties, and the page size is defined by the Limit option. Skip is
+// ignored, since the position comes from the token. Documents, and any
+// projection, must keep the sort fields, not null, or ErrMissingSortKey
+// is returned. It returns the token for the next page, which is empty
+// when no documents remain.
+func (h *Handle) FindAfter(token string, opts ...QueryOptions) (out []Documenter, next string, err error) {
+	defer h.ifSafelyClose()
+	defer wrapErr(&err)
+
+	if err = h.InternalErr; err == nil {
+		var opt QueryOptions
+		if len(opts) == 1 {
+			opt = opts[0]
+		}
+
+		sort := keysetSort(opt.Sort)
+
+		var mapped M
+		if mapped, err = h.mapped(); err == nil {
+			var after []interface{}
+			if after, err = decodeToken(token, sort); err == nil {
+				if after != nil {
+					mapped = withKeyset(mapped, sort, after)
+				}
+
+				// Query one more document than the limit, to know if
+				// there's a next page.
+				limit := opt.Limit
+				opt.Sort, opt.Skip = sort, 0
+				if limit > 0 {
+					opt.Limit = limit + 1
+				}
+
+				var qry *mgo.Query
+				if qry, err = h.query(mapped, opt); err == nil {
+					var result []bson.Raw
+					if err = qry.All(&result); err == nil {
+						if limit > 0 && len(result) > limit {
+							result = result[:limit]
+
+							var last M
+							if err = result[len(result)-1].Unmarshal(&last); err == nil {
+								next, err = encodeToken(last, sort)
+							}
+						}
+
+						if err == nil {
+							out, err = h.documents(result, projection(opt))
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return
+}
+
+// keysetSort returns the sort fields received, with _id appended
+// when missing, making the order of documents unique.
+func keysetSort(fields []string) (sort []string) {
+	sort = make([]string, 0, len(fields)+1)
+
+	hasID := false
+	for _, f := range fields {
+		sort = append(sort, f)
+		if sortKey(f) == "_id" {
+			hasID = true
+		}
+	}
+
+	if !hasID {
+		sort = append(sort, "_id")
+	}
+
+	return
+}
+
+// sortKey returns the field name of a sort field, without the
+// direction prefix.
+func sortKey(field string) (key string) {
+	key = strings.TrimLeft(field, "+-")
+	return
+}
+
+// withKeyset adds to search map m the condition to find only the
+// documents placed after values, on the order defined by sort.
+func withKeyset(m M, sort []string, values []interface{}) (out M) {
+	or := make([]M, len(sort))
+	for i := range sort {
+		clause := M{}
+		for j := 0; j < i; j++ {
+			clause[sortKey(sort[j])] = values[j]
+		}
+
+		op := "$gt"
+		if strings.HasPrefix(sort[i], "-") {
+			op = "$lt"
+		}
+		clause[sortKey(sort[i])] = M{op: values[i]}
+
+		or[i] = clause
+	}
+
+	if len(m) == 0 {
+		out = M{"$or": or}
+	} else {
+		out = M{"$and": []M{m, {"$or": or}}}
+	}
+
+	return
+}
+
+// encodeToken creates a continuation token with the values of the
+// sort fields found on document doc. Returns ErrMissingSortKey if any
+// of them isn't found, or it's null, since null values can't be
+// compared by the next query.
+func encodeToken(doc M, sort []string) (token string, err error) {
+	t := keysetToken{
+		Keys:   sort,
+		Values: make([]interface{}, len(sort)),
+	}
+
+	for i := 0; i < len(sort) && err == nil; i++ {
+		var found bool
+		if t.Values[i], found = lookup(doc, sortKey(sort[i])); !found || t.Values[i] == nil {
+			err = ErrMissingSortKey
+		}
+	}
+
+	if err == nil {
+		var data []byte
+		if data, err = bson.Marshal(t); err == nil {
+			token = base64.RawURLEncoding.EncodeToString(data)
+		}
+	}
+
+	return
+}
+
+// decodeToken returns the values stored on a continuation token,
+// verifying it was created with the same sort fields. An empty token
+// returns nil values.
+func decodeToken(token string, sort []string) (values []interface{}, err error) {
+	if token != "" {
+		var t keysetToken
+		if data, errDecode := base64.RawURLEncoding.DecodeString(token); errDecode != nil {
+			err = ErrInvalidToken
+		} else if bson.Unmarshal(data, &t) != nil || !equalKeys(t.Keys, sort) || len(t.Values) != len(sort) {
+			err = ErrInvalidToken
+		} else {
+			values = t.Values
+		}
+	}
+
+	return
+}
+
+// equalKeys checks if both lists of keys are the same.
+func equalKeys(a, b []string) (equal bool) {
+	if equal = len(a) == len(b); equal {
+		for i := 0; i < len(a) && equal; i++ {
+			equal = a[i] == b[i]
+		}
+	}
+	return
+}
+
+// lookup returns the value on m found by following the dotted path
+// received through the embedded documents, and if the path exists.
+func lookup(m M, path string) (v interface{}, found bool) {
+	keys := strings.Split(path, ".")
+
+	found = true
+	for i := 0; i < len(keys) && found; i++ {
+		if v, found = m[keys[i]]; found && i < len(keys)-1 {
+			m, found = v.(M)
+		}
+	}
+
+	if !found {
+		v = nil
+	}
+
+	return
+}
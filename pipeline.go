@@ -0,0 +1,248 @@
+package mongo
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+// ErrPipelineNotDefined it's an error received when running an
+// aggregation with a nil Pipeline, or with a nil Pipeline on a facet.
+var ErrPipelineNotDefined = errors.New("pipeline not defined")
+
+// Pipeline it's a builder of MongoDB aggregation pipelines, adding
+// stages in the order its methods are called.
+//
+// Pipeline can be used like this:
+//
+//	p := mongo.NewPipeline().
+//		Group("$category", mongo.M{"total": mongo.M{"$sum": "$price"}}).
+//		Sort("-total").
+//		Limit(10)
+//
+//	var result []struct {
+//		Category string  `bson:"_id"`
+//		Total    float64 `bson:"total"`
+//	}
+//	err := h.AggregateTo(p, &result)
+type Pipeline struct {
+	stages []M
+	err    error
+}
+
+// NewPipeline creates an empty Pipeline.
+func NewPipeline() (p *Pipeline) {
+	p = &Pipeline{
+		stages: []M{},
+	}
+	return
+}
+
+// Stage adds a stage defined by operator op and its value v. It's
+// useful for stages without a specific method.
+func (p *Pipeline) Stage(op string, v interface{}) *Pipeline {
+	p.stages = append(p.stages, M{op: v})
+	return p
+}
+
+// Match adds a $match stage, filtering documents with filter.
+func (p *Pipeline) Match(filter M) *Pipeline {
+	return p.Stage("$match", filter)
+}
+
+// Group adds a $group stage, grouping documents by the id expression,
+// and calculating the accumulators on fields for each group.
+func (p *Pipeline) Group(id interface{}, fields M) *Pipeline {
+	group := M{
+		"_id": id,
+	}
+	for k, v := range fields {
+		group[k] = v
+	}
+
+	return p.Stage("$group", group)
+}
+
+// Project adds a $project stage, reshaping documents with fields.
+func (p *Pipeline) Project(fields M) *Pipeline {
+	return p.Stage("$project", fields)
+}
+
+// Sort adds a $sort stage, ordering documents by fields. Fields
+// prefixed by '-' are sorted in descending order.
+func (p *Pipeline) Sort(fields ...string) *Pipeline {
+	sort := make(bson.D, len(fields))
+	for i, f := range fields {
+		order := 1
+		if strings.HasPrefix(f, "-") {
+			order = -1
+		}
+
+		sort[i] = bson.DocElem{
+			Name:  sortKey(f),
+			Value: order,
+		}
+	}
+
+	return p.Stage("$sort", sort)
+}
+
+// Skip adds a $skip stage, skipping the first n documents.
+func (p *Pipeline) Skip(n int) *Pipeline {
+	return p.Stage("$skip", n)
+}
+
+// Limit adds a $limit stage, passing only the first n documents.
+func (p *Pipeline) Limit(n int) *Pipeline {
+	return p.Stage("$limit", n)
+}
+
+// Lookup adds a $lookup stage, joining documents from collection from
+// whose foreignField matches localField, and storing them on as.
+func (p *Pipeline) Lookup(from, localField, foreignField, as string) *Pipeline {
+	return p.Stage("$lookup", M{
+		"from":         from,
+		"localField":   localField,
+		"foreignField": foreignField,
+		"as":           as,
+	})
+}
+
+// Unwind adds an $unwind stage, outputting a document for each element
+// of the array on path. When preserve is true, documents with empty or
+// missing arrays are also output.
+func (p *Pipeline) Unwind(path string, preserve ...bool) *Pipeline {
+	if !strings.HasPrefix(path, "$") {
+		path = "$" + path
+	}
+
+	unwind := M{
+		"path": path,
+	}
+	if len(preserve) == 1 && preserve[0] {
+		unwind["preserveNullAndEmptyArrays"] = true
+	}
+
+	return p.Stage("$unwind", unwind)
+}
+
+// Facet adds a $facet stage, running each of the pipelines received on
+// the same documents, and storing its results on the named fields. A
+// nil pipeline makes the aggregations of p return
+// ErrPipelineNotDefined.
+func (p *Pipeline) Facet(facets map[string]*Pipeline) *Pipeline {
+	facet := M{}
+	for name, fp := range facets {
+		if fp == nil {
+			p.err = ErrPipelineNotDefined
+		} else {
+			if fp.err != nil {
+				p.err = fp.err
+			}
+			facet[name] = fp.Stages()
+		}
+	}
+
+	return p.Stage("$facet", facet)
+}
+
+// Bucket adds a $bucket stage, grouping documents by the groupBy
+// expression on buckets delimited by boundaries. Documents outside
+// boundaries go to the bucket def, if not nil. The output defines
+// the accumulators for each bucket, counting documents if nil.
+func (p *Pipeline) Bucket(groupBy interface{}, boundaries []interface{}, def interface{}, output M) *Pipeline {
+	bucket := M{
+		"groupBy":    groupBy,
+		"boundaries": boundaries,
+	}
+	if def != nil {
+		bucket["default"] = def
+	}
+	if output != nil {
+		bucket["output"] = output
+	}
+
+	return p.Stage("$bucket", bucket)
+}
+
+// Stages returns the stages added to Pipeline.
+func (p *Pipeline) Stages() (stages []M) {
+	stages = p.stages
+	return
+}
+
+// check returns ErrPipelineNotDefined if p, or a pipeline of its
+// facets, is nil.
+func (p *Pipeline) check() (err error) {
+	if p == nil {
+		err = ErrPipelineNotDefined
+	} else {
+		err = p.err
+	}
+	return
+}
+
+// AggregateOptions enumerates different options altering the run of
+// aggregations. MaxTime defines the time limit for the pipeline to run
+// on server.
+type AggregateOptions struct {
+	AllowDiskUse bool
+	BatchSize    int
+	MaxTime      time.Duration
+}
+
+// Aggregate runs the pipeline received on collection connected to
+// Handle, returning the results as new Documenter of the same type of
+// the Handle document. The pipeline starts with a $match stage using
+// the document data. Accepts options to alter the run.
+func (h *Handle) Aggregate(p *Pipeline, opts ...AggregateOptions) (out []Documenter, err error) {
+	var result []bson.Raw
+	if err = h.AggregateTo(p, &result, opts...); err == nil {
+		out, err = h.documents(result, nil)
+	}
+
+	return
+}
+
+// AggregateTo runs the pipeline received on collection connected to
+// Handle, decoding the results onto result, which must be a pointer to
+// a slice. The pipeline starts with a $match stage using the document
+// data. Accepts options to alter the run. Returns ErrPipelineNotDefined
+// if p, or a pipeline of its facets, is nil.
+func (h *Handle) AggregateTo(p *Pipeline, result interface{}, opts ...AggregateOptions) (err error) {
+	defer h.ifSafelyClose()
+	defer wrapErr(&err)
+
+	if err = h.InternalErr; err == nil {
+		err = p.check()
+	}
+
+	if err == nil {
+		var mapped M
+		if mapped, err = h.mapped(); err == nil {
+			stages := p.Stages()
+			if len(mapped) > 0 {
+				stages = append([]M{{"$match": mapped}}, stages...)
+			}
+
+			pipe := h.collection.Pipe(stages)
+			if len(opts) == 1 {
+				if opts[0].AllowDiskUse {
+					pipe = pipe.AllowDiskUse()
+				}
+				if opts[0].BatchSize > 0 {
+					pipe = pipe.Batch(opts[0].BatchSize)
+				}
+				if opts[0].MaxTime > 0 {
+					pipe = pipe.SetMaxTime(opts[0].MaxTime)
+				}
+			}
+
+			err = pipe.All(result)
+		}
+	}
+
+	return
+}
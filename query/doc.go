@@ -0,0 +1,32 @@
+// Copyright 2009 Dênnis Dantas de Sousa. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*
+Package query builds the search maps used by mongo.Handle SearchFor.
+
+I've created this package to avoid the raw maps passed to SearchFor,
+where a typo on an operator or field name silently returns empty
+results. Each condition is created by a function named after its
+operator, and Build validates them before producing the search map.
+
+The package can be used like this:
+
+	m, err := query.Where(
+		query.Gte("price", 10),
+		query.In("tags", "bakery", "sweets"),
+	).Or(
+		query.Eq("name", "bread"),
+		query.Regex("name", "^cake", "i"),
+	).Build()
+
+	// Use m to search documents.
+	p.SearchFor(m).FindAll()
+
+Using BuildFor instead of Build, the field names are also verified
+against the bson tags of a document type:
+
+	m, err := query.Where(query.Eq("nmae", "bread")).BuildFor(&Product{})
+	// errors.Is(err, query.ErrUnknownField) == true
+*/
+package query
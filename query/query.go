@@ -0,0 +1,441 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/ddspog/mongo/internal/bsonutils"
+	"github.com/globalsign/mgo/bson"
+)
+
+var (
+	// ErrInvalidField it's an error received when a filter field is
+	// empty, has empty keys or starts with '$'.
+	ErrInvalidField = errors.New("invalid field")
+	// ErrUnknownField it's an error received on BuildFor, when a filter
+	// field isn't found on the document type.
+	ErrUnknownField = errors.New("unknown field")
+	// ErrInvalidOperator it's an error received when an operator is
+	// used with values or filters it doesn't accept.
+	ErrInvalidOperator = errors.New("invalid operator usage")
+)
+
+// Filter it's a condition on documents, created by the functions of
+// this package named after each query operator.
+type Filter struct {
+	field   string
+	op      string
+	value   interface{}
+	filters []Filter
+}
+
+// Eq matches documents where field equals v.
+func Eq(field string, v interface{}) (f Filter) {
+	f = Filter{field: field, op: "$eq", value: v}
+	return
+}
+
+// Ne matches documents where field doesn't equal v, or doesn't exist.
+func Ne(field string, v interface{}) (f Filter) {
+	f = Filter{field: field, op: "$ne", value: v}
+	return
+}
+
+// Gt matches documents where field is greater than v.
+func Gt(field string, v interface{}) (f Filter) {
+	f = Filter{field: field, op: "$gt", value: v}
+	return
+}
+
+// Gte matches documents where field is greater than or equal to v.
+func Gte(field string, v interface{}) (f Filter) {
+	f = Filter{field: field, op: "$gte", value: v}
+	return
+}
+
+// Lt matches documents where field is less than v.
+func Lt(field string, v interface{}) (f Filter) {
+	f = Filter{field: field, op: "$lt", value: v}
+	return
+}
+
+// Lte matches documents where field is less than or equal to v.
+func Lte(field string, v interface{}) (f Filter) {
+	f = Filter{field: field, op: "$lte", value: v}
+	return
+}
+
+// In matches documents where field equals any of the values. A single
+// slice received is used as the list of values.
+func In(field string, values ...interface{}) (f Filter) {
+	f = Filter{field: field, op: "$in", value: listOf(values)}
+	return
+}
+
+// Nin matches documents where field equals none of the values, or
+// doesn't exist. A single slice received is used as the list of values.
+func Nin(field string, values ...interface{}) (f Filter) {
+	f = Filter{field: field, op: "$nin", value: listOf(values)}
+	return
+}
+
+// Regex matches documents where field matches the regular expression
+// pattern, using the options, made of the flags 'i', 'm', 'x' and 's'.
+func Regex(field, pattern, options string) (f Filter) {
+	f = Filter{field: field, op: "$regex", value: bson.RegEx{
+		Pattern: pattern,
+		Options: options,
+	}}
+	return
+}
+
+// Exists matches documents where field exists, or doesn't when exists
+// is false.
+func Exists(field string, exists bool) (f Filter) {
+	f = Filter{field: field, op: "$exists", value: exists}
+	return
+}
+
+// ElemMatch matches documents where field is an array with at least one
+// element matching all the filters. The filters use fields relative to
+// the elements, or an empty field to match the element itself.
+func ElemMatch(field string, filters ...Filter) (f Filter) {
+	f = Filter{field: field, op: "$elemMatch", filters: filters}
+	return
+}
+
+// Not matches documents that doesn't match the filter received, which
+// must be a condition on a field.
+func Not(filter Filter) (f Filter) {
+	f = Filter{field: filter.field, op: "$not", filters: []Filter{filter}}
+	return
+}
+
+// And matches documents matching all the filters.
+func And(filters ...Filter) (f Filter) {
+	f = Filter{op: "$and", filters: filters}
+	return
+}
+
+// Or matches documents matching at least one of the filters.
+func Or(filters ...Filter) (f Filter) {
+	f = Filter{op: "$or", filters: filters}
+	return
+}
+
+// Nor matches documents matching none of the filters.
+func Nor(filters ...Filter) (f Filter) {
+	f = Filter{op: "$nor", filters: filters}
+	return
+}
+
+// Query it's a builder of search maps, matching documents that match
+// all of its filters.
+type Query struct {
+	filters []Filter
+}
+
+// Where creates a Query with the filters received.
+func Where(filters ...Filter) (q *Query) {
+	q = &Query{
+		filters: filters,
+	}
+	return
+}
+
+// And adds the filters received to Query.
+func (q *Query) And(filters ...Filter) *Query {
+	q.filters = append(q.filters, filters...)
+	return q
+}
+
+// Or adds to Query a filter matching at least one of the filters
+// received.
+func (q *Query) Or(filters ...Filter) *Query {
+	return q.And(Or(filters...))
+}
+
+// Nor adds to Query a filter matching none of the filters received.
+func (q *Query) Nor(filters ...Filter) *Query {
+	return q.And(Nor(filters...))
+}
+
+// Build validates the filters of Query, and translates them to a
+// search map to be used on SearchFor. Conditions on the same field are
+// merged when possible, otherwise they're joined with $and.
+func (q *Query) Build() (m bson.M, err error) {
+	m, err = buildAll(q.filters, false)
+	return
+}
+
+// BuildFor works like Build, also verifying if the fields used on
+// filters exist on the type of doc, following its bson tags.
+func (q *Query) BuildFor(doc interface{}) (m bson.M, err error) {
+	t := reflect.TypeOf(doc)
+	if t == nil {
+		err = fmt.Errorf("%w: document not defined", ErrUnknownField)
+	}
+
+	for i := 0; i < len(q.filters) && err == nil; i++ {
+		err = checkFields(t, "", q.filters[i])
+	}
+
+	if err == nil {
+		m, err = q.Build()
+	}
+
+	return
+}
+
+// buildAll translates filters to a single search map. When elem is
+// true, the filters are applied on array elements, accepting empty
+// fields.
+func buildAll(filters []Filter, elem bool) (m bson.M, err error) {
+	parts := make([]bson.M, len(filters))
+	for i := 0; i < len(filters) && err == nil; i++ {
+		parts[i], err = build(filters[i], elem)
+	}
+
+	if err == nil {
+		m = bson.M{}
+		merged := true
+		for i := 0; i < len(parts) && merged; i++ {
+			merged = merge(m, parts[i])
+		}
+
+		if !merged {
+			m = bson.M{"$and": parts}
+		}
+	}
+
+	return
+}
+
+// build translates a single filter to a search map.
+func build(f Filter, elem bool) (m bson.M, err error) {
+	switch f.op {
+	case "$and", "$or", "$nor":
+		if len(f.filters) == 0 {
+			err = fmt.Errorf("%w: %s without filters", ErrInvalidOperator, f.op)
+		} else {
+			parts := make([]bson.M, len(f.filters))
+			for i := 0; i < len(f.filters) && err == nil; i++ {
+				parts[i], err = build(f.filters[i], elem)
+			}
+
+			if err == nil {
+				m = bson.M{f.op: parts}
+			}
+		}
+	default:
+		var cond interface{}
+		if cond, err = condition(f, f.field == ""); err == nil {
+			if err = checkField(f.field, elem); err == nil {
+				if f.field == "" {
+					m = cond.(bson.M)
+				} else {
+					m = bson.M{f.field: cond}
+				}
+			}
+		}
+	}
+
+	return
+}
+
+// condition translates the operator of a filter on a field to the
+// condition on its value. When explicit is true, equality is written
+// with $eq.
+func condition(f Filter, explicit bool) (cond interface{}, err error) {
+	switch f.op {
+	case "$eq":
+		cond = f.value
+		if explicit {
+			cond = bson.M{"$eq": f.value}
+		}
+	case "$ne", "$gt", "$gte", "$lt", "$lte":
+		if f.value == nil && f.op != "$ne" {
+			err = fmt.Errorf("%w: %s with nil value on %q", ErrInvalidOperator, f.op, f.field)
+		} else {
+			cond = bson.M{f.op: f.value}
+		}
+	case "$in", "$nin":
+		if len(f.value.([]interface{})) == 0 {
+			err = fmt.Errorf("%w: %s without values on %q", ErrInvalidOperator, f.op, f.field)
+		} else {
+			cond = bson.M{f.op: f.value}
+		}
+	case "$regex":
+		re := f.value.(bson.RegEx)
+		if strings.Trim(re.Options, "imxs") != "" {
+			err = fmt.Errorf("%w: %s with options %q on %q", ErrInvalidOperator, f.op, re.Options, f.field)
+		} else if explicit {
+			cond = bson.M{"$regex": re.Pattern, "$options": re.Options}
+		} else {
+			cond = re
+		}
+	case "$exists":
+		cond = bson.M{f.op: f.value}
+	case "$elemMatch":
+		cond, err = elemMatch(f)
+	case "$not":
+		cond, err = not(f)
+	default:
+		err = fmt.Errorf("%w: unknown operator %q", ErrInvalidOperator, f.op)
+	}
+
+	return
+}
+
+// elemMatch translates an ElemMatch filter to its condition, verifying
+// if its filters don't mix empty with named fields.
+func elemMatch(f Filter) (cond interface{}, err error) {
+	named := 0
+	for _, sub := range f.filters {
+		if sub.field != "" {
+			named++
+		}
+	}
+
+	if len(f.filters) == 0 {
+		err = fmt.Errorf("%w: $elemMatch without filters on %q", ErrInvalidOperator, f.field)
+	} else if named > 0 && named < len(f.filters) {
+		err = fmt.Errorf("%w: $elemMatch mixing element and field filters on %q", ErrInvalidOperator, f.field)
+	} else {
+		var sub bson.M
+		if sub, err = buildAll(f.filters, true); err == nil {
+			if _, found := sub["$and"]; found && named == 0 {
+				err = fmt.Errorf("%w: $elemMatch repeating operators on %q", ErrInvalidOperator, f.field)
+			} else {
+				cond = bson.M{"$elemMatch": sub}
+			}
+		}
+	}
+
+	return
+}
+
+// not translates a Not filter to its condition, verifying if the filter
+// negated is a condition on a field.
+func not(f Filter) (cond interface{}, err error) {
+	switch inner := f.filters[0]; inner.op {
+	case "$and", "$or", "$nor", "$not":
+		err = fmt.Errorf("%w: $not of %s, use Nor instead", ErrInvalidOperator, inner.op)
+	default:
+		var negated interface{}
+		if negated, err = condition(inner, true); err == nil {
+			if m, ok := negated.(bson.M); ok && m["$regex"] != nil {
+				negated = inner.value
+			}
+			cond = bson.M{"$not": negated}
+		}
+	}
+
+	return
+}
+
+// merge adds the conditions of part to m, combining operators on the
+// same field. Returns false when they can't be combined.
+func merge(m, part bson.M) (merged bool) {
+	merged = true
+	for k, v := range part {
+		current, found := m[k]
+		if !found {
+			m[k] = v
+			continue
+		}
+
+		a, okA := current.(bson.M)
+		b, okB := v.(bson.M)
+		if !okA || !okB || strings.HasPrefix(k, "$") {
+			merged = false
+			break
+		}
+
+		combined := bson.M{}
+		for op, cond := range a {
+			combined[op] = cond
+		}
+		for op, cond := range b {
+			if _, repeated := combined[op]; repeated || !strings.HasPrefix(op, "$") {
+				merged = false
+				break
+			}
+			combined[op] = cond
+		}
+
+		if !merged {
+			break
+		}
+		m[k] = combined
+	}
+
+	return
+}
+
+// checkField verifies if field is a valid path. Empty fields are
+// only valid on elements of arrays.
+func checkField(field string, elem bool) (err error) {
+	if field == "" {
+		if !elem {
+			err = fmt.Errorf("%w: empty field", ErrInvalidField)
+		}
+	} else if strings.HasPrefix(field, "$") {
+		err = fmt.Errorf("%w: %q starts with '$', use the operator functions", ErrInvalidField, field)
+	} else {
+		for _, key := range strings.Split(field, ".") {
+			if key == "" {
+				err = fmt.Errorf("%w: %q has empty keys", ErrInvalidField, field)
+				break
+			}
+		}
+	}
+
+	return
+}
+
+// checkFields verifies if the fields used by filter f exist on type
+// t, prefixed by the path of an array whose elements are matched.
+func checkFields(t reflect.Type, prefix string, f Filter) (err error) {
+	path := prefix + f.field
+	if f.field != "" && !bsonutils.HasPath(t, path) {
+		err = fmt.Errorf("%w: %q on %s", ErrUnknownField, path, t)
+	}
+
+	filters := f.filters
+	switch f.op {
+	case "$elemMatch":
+		prefix = path + "."
+	case "$not":
+		// The negated filter uses the same field, only its own filters
+		// need to be verified.
+		filters = f.filters[0].filters
+		if f.filters[0].op == "$elemMatch" {
+			prefix = path + "."
+		}
+	}
+
+	for i := 0; i < len(filters) && err == nil; i++ {
+		err = checkFields(t, prefix, filters[i])
+	}
+
+	return
+}
+
+// listOf returns the values received, expanding a single slice
+// received to its elements.
+func listOf(values []interface{}) (list []interface{}) {
+	list = values
+	if len(values) == 1 {
+		if v := reflect.ValueOf(values[0]); v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 {
+			list = make([]interface{}, v.Len())
+			for i := range list {
+				list[i] = v.Index(i).Interface()
+			}
+		}
+	}
+
+	return
+}
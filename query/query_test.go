@@ -0,0 +1,163 @@
+// +build !acceptance
+
+package query
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ddspog/bdd"
+	"github.com/globalsign/mgo/bson"
+)
+
+// item it's a type used as elements of arrays on document.
+type item struct {
+	Name  string `bson:"name"`
+	Price int    `bson:"price"`
+}
+
+// base it's a type inlined on document.
+type base struct {
+	ID bson.ObjectId `bson:"_id"`
+}
+
+// document it's a type used to verify field names on BuildFor.
+type document struct {
+	base   `bson:",inline"`
+	Name   string   `bson:"name"`
+	Tags   []string `bson:"tags"`
+	Items  []item   `bson:"items"`
+	Meta   bson.M   `bson:"meta"`
+	Hidden string   `bson:"-"`
+}
+
+// Feature Build search maps with Query
+// - As a developer,
+// - I want to build search maps with functions named after operators,
+// - So that typos on operators can't silently return empty results.
+func Test_Build_search_maps_with_Query(t *testing.T) {
+	given, like, s := bdd.Sentences().All()
+
+	given(t, "a Query q with filters %[1]v", func(when bdd.When, args ...interface{}) {
+		q := Where(args[0].([]Filter)...)
+
+		when("m, err := q.Build() is called", func(it bdd.It) {
+			m, err := q.Build()
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.NoError(err)
+			})
+			it("should return %[2]v", func(assert bdd.Assert) {
+				assert.Equal(args[1].(bson.M), m)
+			})
+		})
+	}, like(
+		s([]Filter{}, bson.M{}),
+		s([]Filter{Eq("name", "bread")}, bson.M{"name": "bread"}),
+		s([]Filter{Gte("price", 1), Lt("price", 5)}, bson.M{"price": bson.M{"$gte": 1, "$lt": 5}}),
+		s([]Filter{Eq("price", 1), Gt("price", 0)}, bson.M{"$and": []bson.M{{"price": 1}, {"price": bson.M{"$gt": 0}}}}),
+		s([]Filter{In("tags", []string{"a", "b"})}, bson.M{"tags": bson.M{"$in": []interface{}{"a", "b"}}}),
+		s([]Filter{Nin("tags", "a"), Exists("tags", true)}, bson.M{"tags": bson.M{"$nin": []interface{}{"a"}, "$exists": true}}),
+		s([]Filter{Regex("name", "^br", "i")}, bson.M{"name": bson.RegEx{Pattern: "^br", Options: "i"}}),
+		s([]Filter{Not(Regex("name", "^br", ""))}, bson.M{"name": bson.M{"$not": bson.RegEx{Pattern: "^br"}}}),
+		s([]Filter{Not(Eq("name", "bread"))}, bson.M{"name": bson.M{"$not": bson.M{"$eq": "bread"}}}),
+		s([]Filter{ElemMatch("items", Eq("name", "a"), Gt("price", 2))}, bson.M{"items": bson.M{"$elemMatch": bson.M{"name": "a", "price": bson.M{"$gt": 2}}}}),
+		s([]Filter{ElemMatch("scores", Gte("", 80), Lt("", 90))}, bson.M{"scores": bson.M{"$elemMatch": bson.M{"$gte": 80, "$lt": 90}}}),
+		s([]Filter{Or(Eq("name", "a"), Ne("name", "b"))}, bson.M{"$or": []bson.M{{"name": "a"}, {"name": bson.M{"$ne": "b"}}}}),
+		s([]Filter{Nor(Lte("price", 1)), And(Eq("name", "a"))}, bson.M{"$nor": []bson.M{{"price": bson.M{"$lte": 1}}}, "$and": []bson.M{{"name": "a"}}}),
+	))
+
+	given(t, "a Query q with invalid filters %[1]v", func(when bdd.When, args ...interface{}) {
+		q := Where(args[0].([]Filter)...)
+
+		when("m, err := q.Build() is called", func(it bdd.It) {
+			m, err := q.Build()
+
+			it("should return %[2]v", func(assert bdd.Assert) {
+				assert.True(errors.Is(err, args[1].(error)))
+			})
+			it("should return a nil map", func(assert bdd.Assert) {
+				assert.Nil(m)
+			})
+		})
+	}, like(
+		s([]Filter{Eq("", 1)}, ErrInvalidField),
+		s([]Filter{Eq("$gte", 1)}, ErrInvalidField),
+		s([]Filter{Eq("items..name", 1)}, ErrInvalidField),
+		s([]Filter{In("tags")}, ErrInvalidOperator),
+		s([]Filter{Gt("price", nil)}, ErrInvalidOperator),
+		s([]Filter{Regex("name", "^br", "g")}, ErrInvalidOperator),
+		s([]Filter{Or()}, ErrInvalidOperator),
+		s([]Filter{Not(Or(Eq("name", "a")))}, ErrInvalidOperator),
+		s([]Filter{ElemMatch("items")}, ErrInvalidOperator),
+		s([]Filter{ElemMatch("items", Eq("name", "a"), Gt("", 2))}, ErrInvalidOperator),
+	))
+
+	given(t, "a Query q built with Where(Eq('name', 'a')).Or(Eq('price', 1), Eq('price', 2)).Nor(Exists('tags', false))", func(when bdd.When) {
+		q := Where(Eq("name", "a")).Or(Eq("price", 1), Eq("price", 2)).Nor(Exists("tags", false))
+
+		when("m, err := q.Build() is called", func(it bdd.It) {
+			m, err := q.Build()
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.NoError(err)
+			})
+			it("should return all conditions", func(assert bdd.Assert) {
+				assert.Equal(bson.M{
+					"name": "a",
+					"$or":  []bson.M{{"price": 1}, {"price": 2}},
+					"$nor": []bson.M{{"tags": bson.M{"$exists": false}}},
+				}, m)
+			})
+		})
+	})
+}
+
+// Feature Verify fields of search maps with Query
+// - As a developer,
+// - I want to verify the fields used on filters against a document type,
+// - So that typos on field names can't silently return empty results.
+func Test_Verify_fields_of_search_maps_with_Query(t *testing.T) {
+	given, like, s := bdd.Sentences().All()
+
+	given(t, "a Query q with filters on known fields %[1]v", func(when bdd.When, args ...interface{}) {
+		q := Where(args[0].([]Filter)...)
+
+		when("m, err := q.BuildFor(&document{}) is called", func(it bdd.It) {
+			_, err := q.BuildFor(&document{})
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.NoError(err)
+			})
+		})
+	}, like(
+		s([]Filter{Eq("_id", bson.NewObjectId()), Eq("name", "a")}),
+		s([]Filter{Eq("tags", "a"), Eq("tags.0", "a")}),
+		s([]Filter{Eq("items.name", "a"), Gt("items.$.price", 1)}),
+		s([]Filter{ElemMatch("items", Eq("name", "a"))}),
+		s([]Filter{Not(ElemMatch("items", Eq("price", 1)))}),
+		s([]Filter{Or(Exists("meta.anything.deep", true))}),
+	))
+
+	given(t, "a Query q with filters on unknown fields %[1]v", func(when bdd.When, args ...interface{}) {
+		q := Where(args[0].([]Filter)...)
+
+		when("m, err := q.BuildFor(&document{}) is called", func(it bdd.It) {
+			m, err := q.BuildFor(&document{})
+
+			it("should return ErrUnknownField", func(assert bdd.Assert) {
+				assert.True(errors.Is(err, ErrUnknownField))
+			})
+			it("should return a nil map", func(assert bdd.Assert) {
+				assert.Nil(m)
+			})
+		})
+	}, like(
+		s([]Filter{Eq("nmae", "a")}),
+		s([]Filter{Eq("hidden", "a")}),
+		s([]Filter{Eq("name.first", "a")}),
+		s([]Filter{ElemMatch("items", Eq("cost", 1))}),
+		s([]Filter{Not(ElemMatch("items", Eq("cost", 1)))}),
+		s([]Filter{Or(Eq("name", "a"), Eq("tag", "a"))}),
+	))
+}
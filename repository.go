@@ -0,0 +1,208 @@
+package mongo
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/globalsign/mgo"
+)
+
+// Repository it's a stateless access to a collection, safe to be
+// shared between goroutines. Unlike Handle, it doesn't store documents
+// or search maps: they're received on each call, which runs with its
+// own socket.
+//
+// Repository can be used like this:
+//
+//	products := mongo.NewRepository("products", &Product{}, indexes...)
+//
+//	// On any goroutine.
+//	docs, err := products.FindAll(mongo.M{"price": mongo.M{"$lt": 10}})
+type Repository struct {
+	name     string
+	doc      Documenter
+	indexes  []mgo.Index
+	strict   bool
+	err      error
+	indexing *indexing
+}
+
+// indexing it's the state of the indexes loaded by a Repository,
+// shared with its copies.
+type indexing struct {
+	mu     sync.Mutex
+	loaded bool
+}
+
+// NewRepository creates a new Repository for the collection with name
+// received, loading the optional indexes onto collection. It needs a
+// document not nil, used to create the documents found, or every call
+// returns DocNotDefined. Errors loading the indexes are returned by the
+// calls, which try loading them again, until one of them succeeds.
+func NewRepository(name string, doc Documenter, indexes ...mgo.Index) (r *Repository) {
+	r = &Repository{
+		name:     name,
+		doc:      doc,
+		indexes:  indexes,
+		indexing: &indexing{},
+	}
+
+	if doc == nil || reflect.ValueOf(doc).IsNil() {
+		r.err = DocNotDefined
+	} else {
+		r.Handle(nil, nil).Close()
+	}
+
+	return
+}
+
+// Name returns the name of collection that Repository accesses.
+func (r *Repository) Name() (n string) {
+	n = r.name
+	return
+}
+
+// Strict returns a copy of Repository verifying the paths used on
+// search maps, sorts and projections, like a strict Handle.
+func (r *Repository) Strict() (s *Repository) {
+	copied := *r
+	copied.strict = true
+	s = &copied
+	return
+}
+
+// Handle returns a new Handle on collection of Repository, storing the
+// document d and the search map filter. Empty filters match all
+// documents, instead of the document data. The Handle is closed after
+// its first operation, and isn't shared, so it can be used for any
+// operation not available on Repository.
+func (r *Repository) Handle(d Documenter, filter M) (h *Handle) {
+	sk := NewSocket()
+
+	if d == nil && r.err == nil {
+		d = r.doc.New()
+	}
+	if filter == nil {
+		filter = M{}
+	}
+
+	h = &Handle{
+		safely:            true,
+		strict:            r.strict,
+		searchOnly:        true,
+		socket:            sk,
+		collection:        sk.DB().C(r.name),
+		collectionName:    r.name,
+		collectionIndexes: r.indexes,
+		SearchMapV:        filter,
+	}
+
+	if h.InternalErr = r.err; h.InternalErr == nil {
+		h.SetDocument(d)
+		if h.InternalErr == nil {
+			h.InternalErr = r.indexing.load(h)
+		}
+	}
+
+	return
+}
+
+// load loads the indexes of h onto its collection, unless a previous
+// call loaded them. Failures are tried again on next call.
+func (i *indexing) load(h *Handle) (err error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if !i.loaded {
+		h.ensureIndexes()
+		if err = h.InternalErr; err == nil {
+			i.loaded = true
+		}
+	}
+
+	return
+}
+
+// Count returns the number of documents matching filter. Accepts
+// options to alter result.
+func (r *Repository) Count(filter M, opts ...CountOptions) (n int, err error) {
+	n, err = r.Handle(nil, filter).Count(opts...)
+	return
+}
+
+// Find search for a document matching filter. Accepts options to alter
+// result.
+func (r *Repository) Find(filter M, opts ...QueryOptions) (out Documenter, err error) {
+	out, err = r.Handle(nil, filter).Find(opts...)
+	return
+}
+
+// FindAll search for all documents matching filter. Accepts options to
+// alter result.
+func (r *Repository) FindAll(filter M, opts ...QueryOptions) (out []Documenter, err error) {
+	out, err = r.Handle(nil, filter).FindAll(opts...)
+	return
+}
+
+// FindPage search for a page of documents matching filter, delimited
+// by the Skip and Limit options, and the total number of documents
+// matching filter.
+func (r *Repository) FindPage(filter M, opts ...QueryOptions) (out []Documenter, total int, err error) {
+	out, total, err = r.Handle(nil, filter).FindPage(opts...)
+	return
+}
+
+// Iter search for all documents matching filter, returning an iterator
+// through them. The iterator must be closed after use.
+func (r *Repository) Iter(filter M, opts ...QueryOptions) (it *Iter) {
+	it = r.Handle(nil, filter).Iter(opts...)
+	return
+}
+
+// Insert puts the document d on collection, generating its ID if not
+// defined, and its created_on.
+func (r *Repository) Insert(d Documenter) (err error) {
+	err = r.Handle(d, nil).Insert()
+	return
+}
+
+// Update replaces the document matching id with the document d,
+// setting its updated_on.
+func (r *Repository) Update(id ObjectId, d Documenter) (err error) {
+	err = r.Handle(d, nil).Update(id)
+	return
+}
+
+// UpdateWith updates the document matching id, applying the update
+// operators on ops.
+func (r *Repository) UpdateWith(id ObjectId, ops *Ops) (err error) {
+	err = r.Handle(nil, nil).UpdateWith(id, ops)
+	return
+}
+
+// UpdateAll updates all documents matching filter, with the updates
+// accepted by Handle UpdateAll.
+func (r *Repository) UpdateAll(filter M, update interface{}) (info *mgo.ChangeInfo, err error) {
+	info, err = r.Handle(nil, filter).UpdateAll(update)
+	return
+}
+
+// Upsert inserts or updates the document d, matched by filter, or by
+// its ID when filter is empty. Returns if a new document was created,
+// and its ID.
+func (r *Repository) Upsert(filter M, d Documenter) (created bool, id ObjectId, err error) {
+	created, id, err = r.Handle(d, filter).Upsert()
+	return
+}
+
+// Remove deletes the document matching id.
+func (r *Repository) Remove(id ObjectId) (err error) {
+	err = r.Handle(nil, nil).Remove(id)
+	return
+}
+
+// RemoveAll deletes all documents matching filter.
+func (r *Repository) RemoveAll(filter M) (info *mgo.ChangeInfo, err error) {
+	info, err = r.Handle(nil, filter).RemoveAll()
+	return
+}
@@ -0,0 +1,133 @@
+// +build !acceptance
+
+package mongo
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/ddspog/bdd"
+	"github.com/globalsign/mgo"
+)
+
+// Feature Find documents with Repository
+// - As a developer,
+// - I want to search documents passing the filter on each call,
+// - So that I can share a single Repository between requests.
+func Test_Find_documents_with_Repository(t *testing.T) {
+	given, like, s := bdd.Sentences().All()
+
+	given(t, "a products Repository r and the filter %[1]v", func(when bdd.When, args ...interface{}) {
+		r := NewRepository("products", newProduct())
+
+		when("r.FindAll(filter) and r.Count(filter) are called", func(it bdd.It) {
+			da, errFind := r.FindAll(args[0].(M))
+			n, errCount := r.Count(args[0].(M))
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.Nil(errFind)
+				assert.Nil(errCount)
+			})
+			it("should find and count %[2]v documents", func(assert bdd.Assert) {
+				assert.Len(da, args[1].(int))
+				assert.Equal(args[1].(int), n)
+			})
+		})
+	}, like(
+		s(M(nil), len(fixtures)),
+		s(M{}, len(fixtures)),
+		s(M{"_id": fixture(1).ID()}, 1),
+		s(M{"_id": ObjectIdHex(idE)}, 0),
+	))
+
+	given(t, "a products Repository r created with a nil document", func(when bdd.When) {
+		var nilProduct *product
+		r := NewRepository("products", nilProduct)
+
+		when("r.FindAll(nil) and r.Handle(nil, nil) are called", func(it bdd.It) {
+			_, errFind := r.FindAll(nil)
+			h := r.Handle(nil, nil)
+
+			it("should return DocNotDefined", func(assert bdd.Assert) {
+				assert.Equal(DocNotDefined, errFind)
+				assert.Equal(DocNotDefined, h.InternalErr)
+			})
+		})
+	})
+
+	given(t, "a products Repository r created with an index failing to load", func(when bdd.When) {
+		r := NewRepository("products", newProduct(), mgo.Index{Key: []string{"$invalid"}})
+
+		when("r.Count(nil) is called before and after fixing the index", func(it bdd.It) {
+			_, errFirst := r.Count(nil)
+			r.indexes = []mgo.Index{{Key: []string{"created_on"}}}
+			n, errSecond := r.Count(nil)
+
+			it("should return an error only before fixing it", func(assert bdd.Assert) {
+				assert.Error(errFirst)
+				assert.Nil(errSecond)
+			})
+			it("should count all documents after fixing it", func(assert bdd.Assert) {
+				assert.Equal(len(fixtures), n)
+			})
+		})
+	})
+
+	given(t, "a strict products Repository r", func(when bdd.When) {
+		r := NewRepository("products", newProduct()).Strict()
+
+		when("r.Find(M{'name': 'bread'}) is called", func(it bdd.It) {
+			_, err := r.Find(M{"name": "bread"})
+
+			it("should return an UnknownFieldError", func(assert bdd.Assert) {
+				assert.Equal(&UnknownFieldError{Path: "name", Usage: "filter", Type: "*mongo.product"}, err)
+			})
+		})
+	})
+}
+
+// Feature Share Repository between goroutines
+// - As a developer,
+// - I want to use the same Repository on various goroutines,
+// - So that I don't need to create one for each request.
+func Test_Share_Repository_between_goroutines(t *testing.T) {
+	defer cleanChanges()
+	given := bdd.Sentences().Given()
+
+	given(t, "a products Repository r used by 10 goroutines", func(when bdd.When) {
+		r := NewRepository("products", newProduct())
+
+		when("each goroutine inserts a product, finds and removes it", func(it bdd.It) {
+			errs := make(chan error, 30)
+
+			var wg sync.WaitGroup
+			for i := 0; i < 10; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+
+					p := newProduct()
+					errs <- r.Insert(p)
+
+					_, err := r.Find(M{"_id": p.ID()})
+					errs <- err
+					errs <- r.Remove(p.ID())
+				}()
+			}
+			wg.Wait()
+			close(errs)
+
+			it("should return no errors", func(assert bdd.Assert) {
+				for err := range errs {
+					assert.Nil(err)
+				}
+			})
+
+			n, err := r.Count(nil)
+			it("should keep only the fixtures", func(assert bdd.Assert) {
+				assert.Nil(err)
+				assert.Equal(len(fixtures), n)
+			})
+		})
+	})
+}
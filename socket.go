@@ -1,6 +1,10 @@
 package mongo
 
 import (
+	"context"
+	"sync"
+	"time"
+
 	"github.com/globalsign/mgo"
 )
 
@@ -8,16 +12,19 @@ import (
 // database, that can be closed after using it. It's used to make calls
 // to the mongo collections parallel and independent.
 type DatabaseSocket struct {
-	db   chan *mgo.Database
-	quit chan bool
+	db       chan *mgo.Database
+	quit     chan bool
+	released chan bool
+	release  sync.Once
 }
 
 // NewSocket creates a new DatabaseSocket, initializing channel values,
 // supporting the DB calls.
 func NewSocket() (db *DatabaseSocket) {
 	db = &DatabaseSocket{
-		db:   make(chan *mgo.Database),
-		quit: make(chan bool),
+		db:       make(chan *mgo.Database),
+		quit:     make(chan bool),
+		released: make(chan bool),
 	}
 	return
 }
@@ -34,7 +41,44 @@ func (d *DatabaseSocket) DB() (db *mgo.Database) {
 	return <-d.db
 }
 
+// DBCtx returns the database object like DB, stopping when ctx is done.
+// The deadline of ctx is used as socket timeout on the session. When
+// ctx is done, the session is released, aborting any operation not
+// started yet, without needing to call Close.
+func (d *DatabaseSocket) DBCtx(ctx context.Context) (db *mgo.Database, err error) {
+	if err = ctx.Err(); err == nil {
+		go ConsumeDatabaseOnSession(func(db *mgo.Database) {
+			if deadline, ok := ctx.Deadline(); ok && db != nil {
+				db.Session.SetSocketTimeout(time.Until(deadline))
+			}
+
+			select {
+			case d.db <- db:
+				select {
+				case <-d.quit:
+				case <-ctx.Done():
+					d.release.Do(func() {
+						close(d.released)
+					})
+				}
+			case <-ctx.Done():
+			}
+		})
+
+		select {
+		case db = <-d.db:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+
+	return
+}
+
 // Close the socket open when DB is called.
 func (d *DatabaseSocket) Close() {
-	d.quit <- true
+	select {
+	case d.quit <- true:
+	case <-d.released:
+	}
 }
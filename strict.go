@@ -0,0 +1,134 @@
+package mongo
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/ddspog/mongo/internal/bsonutils"
+)
+
+// UnknownFieldError it's an error received on strict Handles, when a
+// search map, sort or projection uses a path not found on the bson
+// tags of the document type.
+type UnknownFieldError struct {
+	Path  string
+	Usage string
+	Type  string
+}
+
+// Error returns the description of UnknownFieldError.
+func (e *UnknownFieldError) Error() string {
+	return fmt.Sprintf("unknown field %q used on %s of %s", e.Path, e.Usage, e.Type)
+}
+
+// Strict sets Handle to verify the paths used on search maps, sorts
+// and projections against the bson tags of the document type, failing
+// with an UnknownFieldError instead of silently matching nothing. It's
+// kept after Clean.
+func (h *Handle) Strict() {
+	h.strict = true
+}
+
+// IsStrict checks if Strict was called on Handle.
+func (h *Handle) IsStrict() (strict bool) {
+	strict = h.strict
+	return
+}
+
+// checkFilter verifies, on strict Handles, if the fields used on the
+// search map m exist on the document type.
+func (h *Handle) checkFilter(m M) (err error) {
+	if h.strict {
+		err = h.checkFilterPaths(m, "")
+	}
+	return
+}
+
+// checkFilterPaths verifies the fields of search map m, prefixed by
+// the path of an array whose elements are matched. Logical operators
+// are followed, and other top level operators ignored.
+func (h *Handle) checkFilterPaths(m M, prefix string) (err error) {
+	for k, v := range m {
+		if err != nil {
+			break
+		}
+
+		switch k {
+		case "$and", "$or", "$nor":
+			for _, sub := range filterList(v) {
+				if err = h.checkFilterPaths(sub, prefix); err != nil {
+					break
+				}
+			}
+		default:
+			if !strings.HasPrefix(k, "$") {
+				if err = h.checkPaths("filter", prefix+k); err == nil {
+					err = h.checkElemMatch(v, prefix+k)
+				}
+			}
+		}
+	}
+
+	return
+}
+
+// checkElemMatch verifies the fields used on $elemMatch conditions of
+// cond, relative to the array on path, even when negated.
+func (h *Handle) checkElemMatch(cond interface{}, path string) (err error) {
+	if ops, ok := cond.(M); ok {
+		if sub, ok := ops["$elemMatch"].(M); ok {
+			err = h.checkFilterPaths(sub, path+".")
+		}
+		if not, ok := ops["$not"]; ok && err == nil {
+			err = h.checkElemMatch(not, path)
+		}
+	}
+
+	return
+}
+
+// checkPaths verifies, on strict Handles, if the paths received exist
+// on the document type, describing where they're used with usage.
+func (h *Handle) checkPaths(usage string, paths ...string) (err error) {
+	if h.strict && h.Document() != nil {
+		t := reflect.TypeOf(h.Document())
+		for i := 0; i < len(paths) && err == nil; i++ {
+			if !bsonutils.HasPath(t, paths[i]) {
+				err = &UnknownFieldError{
+					Path:  paths[i],
+					Usage: usage,
+					Type:  t.String(),
+				}
+			}
+		}
+	}
+
+	return
+}
+
+// checkSort verifies, on strict Handles, if the sort fields received
+// exist on the document type. Text score sorts are ignored.
+func (h *Handle) checkSort(fields []string) (err error) {
+	for i := 0; i < len(fields) && err == nil; i++ {
+		if !strings.HasPrefix(fields[i], "$") {
+			err = h.checkPaths("sort", sortKey(fields[i]))
+		}
+	}
+	return
+}
+
+// filterList returns the search maps of a logical operator value.
+func filterList(v interface{}) (list []M) {
+	switch l := v.(type) {
+	case []M:
+		list = l
+	case []interface{}:
+		for _, sub := range l {
+			if m, ok := sub.(M); ok {
+				list = append(list, m)
+			}
+		}
+	}
+	return
+}
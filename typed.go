@@ -0,0 +1,161 @@
+package mongo
+
+import (
+	"errors"
+
+	"github.com/globalsign/mgo"
+)
+
+// ErrTypeMismatch it's an error received when a document found isn't
+// of the type of TypedHandle, like when New of its document returns
+// another type.
+var ErrTypeMismatch = errors.New("document isn't of the type of TypedHandle")
+
+// TypedHandle it's a Handle working with documents of type T, taking
+// and returning them without the casts needed on Handle. It embeds
+// Handle, so all of its operations are still available.
+//
+// TypedHandle can be used like this:
+//
+//	p := mongo.NewTypedHandle("products", &Product{})
+//	prod, err := p.Safely().SearchFor(mongo.M{"name": "bread"}).Find()
+//	// prod is a *Product.
+type TypedHandle[T Documenter] struct {
+	*Handle
+}
+
+// NewTypedHandle creates a new TypedHandle, like NewHandle does. It
+// needs the name for collection to link, and a document not nil to
+// perform some operations. It also accept optional indexes to be
+// loaded onto collection.
+func NewTypedHandle[T Documenter](name string, doc T, indexes ...mgo.Index) (h *TypedHandle[T]) {
+	h = &TypedHandle[T]{
+		Handle: NewHandle(name, doc, indexes...),
+	}
+	return
+}
+
+// Safely sets TypedHandle to close after any operation, returns
+// TypedHandle for chaining purposes.
+func (h *TypedHandle[T]) Safely() *TypedHandle[T] {
+	h.Handle.Safely()
+	return h
+}
+
+// Strict sets TypedHandle to verify fields used on searches, returns
+// TypedHandle for chaining purposes.
+func (h *TypedHandle[T]) Strict() *TypedHandle[T] {
+	h.Handle.Strict()
+	return h
+}
+
+// Clean resets TypedHandle values, returns TypedHandle for chaining
+// purposes.
+func (h *TypedHandle[T]) Clean() *TypedHandle[T] {
+	h.Handle.Clean()
+	return h
+}
+
+// SetDocument sets document d on TypedHandle, returns TypedHandle for
+// chaining purposes.
+func (h *TypedHandle[T]) SetDocument(d T) *TypedHandle[T] {
+	h.Handle.SetDocument(d)
+	return h
+}
+
+// Document returns the document of TypedHandle.
+func (h *TypedHandle[T]) Document() (d T) {
+	d, _ = h.Handle.Document().(T)
+	return
+}
+
+// SearchFor sets search map value for TypedHandle, returns
+// TypedHandle for chaining purposes.
+func (h *TypedHandle[T]) SearchFor(s M) *TypedHandle[T] {
+	h.Handle.SearchFor(s)
+	return h
+}
+
+// Find search for a document matching the doc data on collection
+// connected to TypedHandle. Accepts options to alter result. Returns
+// ErrTypeMismatch if the document found isn't a T.
+func (h *TypedHandle[T]) Find(opts ...QueryOptions) (out T, err error) {
+	var doc Documenter
+	if doc, err = h.Handle.Find(opts...); err == nil {
+		out, err = typed[T](doc)
+	}
+	return
+}
+
+// FindAll search for all documents matching the document data on
+// collection connected to TypedHandle. Accepts options to alter
+// result. Returns ErrTypeMismatch if any document found isn't a T.
+func (h *TypedHandle[T]) FindAll(opts ...QueryOptions) (out []T, err error) {
+	var da []Documenter
+	if da, err = h.Handle.FindAll(opts...); err == nil {
+		out = make([]T, len(da))
+		for i := 0; i < len(da) && err == nil; i++ {
+			out[i], err = typed[T](da[i])
+		}
+
+		if err != nil {
+			out = nil
+		}
+	}
+	return
+}
+
+// Iter search for all documents matching the document data on
+// collection connected to TypedHandle, returning an iterator through
+// them. Accepts options to alter result. The iterator must be closed
+// after use.
+func (h *TypedHandle[T]) Iter(opts ...QueryOptions) (it *TypedIter[T]) {
+	it = &TypedIter[T]{
+		Iter: h.Handle.Iter(opts...),
+	}
+	return
+}
+
+// Insert sets d as document of TypedHandle and puts it on collection
+// connected, generating its ID if not defined, and its created_on.
+func (h *TypedHandle[T]) Insert(d T) (err error) {
+	err = h.SetDocument(d).Handle.Insert()
+	return
+}
+
+// Update sets d as document of TypedHandle and replaces with it the
+// document matching id on collection connected, setting updated_on.
+func (h *TypedHandle[T]) Update(id ObjectId, d T) (err error) {
+	err = h.SetDocument(d).Handle.Update(id)
+	return
+}
+
+// TypedIter it's an Iter returning documents of type T.
+type TypedIter[T Documenter] struct {
+	*Iter
+}
+
+// Next decodes the next document found onto a new T. Returns false
+// when there are no more documents, or an error happened, closing the
+// iterator. A document that isn't a T stops the iteration with
+// ErrTypeMismatch.
+func (it *TypedIter[T]) Next() (doc T, ok bool) {
+	var d Documenter
+	if d, ok = it.Iter.Next(); ok {
+		if doc, it.err = typed[T](d); it.err != nil {
+			ok = false
+			it.Close()
+		}
+	}
+	return
+}
+
+// typed returns the document d as a T, or ErrTypeMismatch if it's of
+// another type.
+func typed[T Documenter](d Documenter) (out T, err error) {
+	var ok bool
+	if out, ok = d.(T); !ok {
+		err = ErrTypeMismatch
+	}
+	return
+}
@@ -0,0 +1,105 @@
+// +build !acceptance
+
+package mongo
+
+import (
+	"testing"
+
+	"github.com/ddspog/bdd"
+)
+
+// Feature Manipulate documents with TypedHandle
+// - As a developer,
+// - I want to use a Handle taking and returning my own document type,
+// - So that I don't need to write casts or wrappers for each document.
+func Test_Manipulate_documents_with_TypedHandle(t *testing.T) {
+	defer cleanChanges()
+	given := bdd.Sentences().Given()
+
+	given(t, "a TypedHandle p of products with documents "+colFixtures, func(when bdd.When) {
+		p := NewTypedHandle("products", newProduct())
+
+		when("p.SearchFor(M{'_id': fixture(1).ID()}).Find() is called", func(it bdd.It) {
+			prod, err := p.Safely().SearchFor(M{"_id": fixture(1).ID()}).Find()
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.Nil(err)
+			})
+			it("should return a *product equal to fixture(1)", func(assert bdd.Assert) {
+				assert.Equal(fixture(1).ID(), prod.ID())
+				assert.Equal(fixture(1).CreatedOn(), prod.CreatedOn())
+			})
+		})
+
+		when("p.FindAll() and p.Iter() are called", func(it bdd.It) {
+			proda, errFind := NewTypedHandle("products", newProduct()).Safely().FindAll(QueryOptions{
+				Sort: []string{"_id"},
+			})
+
+			iter := NewTypedHandle("products", newProduct()).Safely().Iter(QueryOptions{
+				Sort: []string{"_id"},
+			})
+			var ids []ObjectId
+			for prod, ok := iter.Next(); ok; prod, ok = iter.Next() {
+				ids = append(ids, prod.IDV)
+			}
+			errIter := iter.Close()
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.Nil(errFind)
+				assert.Nil(errIter)
+			})
+			it("should return all products in order", func(assert bdd.Assert) {
+				assert.Len(proda, len(fixtures))
+				assert.Equal([]ObjectId{proda[0].IDV, proda[1].IDV, proda[2].IDV}, ids)
+				assert.Equal([]ObjectId{fixture(1).IDV, fixture(2).IDV, fixture(3).IDV}, ids)
+			})
+		})
+
+		when("p.Insert(d) and p.Update(d.ID(), d) are called", func(it bdd.It) {
+			d := newProduct()
+			errInsert := NewTypedHandle("products", newProduct()).Safely().Insert(d)
+			errUpdate := NewTypedHandle("products", newProduct()).Safely().Update(d.ID(), d)
+
+			found, errFind := NewTypedHandle("products", newProduct()).Safely().SearchFor(M{"_id": d.ID()}).Find()
+
+			it("should return no errors", func(assert bdd.Assert) {
+				assert.Nil(errInsert)
+				assert.Nil(errUpdate)
+				assert.Nil(errFind)
+			})
+			it("should store d with its generated values", func(assert bdd.Assert) {
+				assert.True(d.ID().Valid())
+				assert.Equal(d.ID(), found.ID())
+				assert.NotEqual(int64(0), found.UpdatedOn())
+			})
+		})
+	})
+
+	given(t, "a TypedHandle p of mislabeled products, whose New returns a *product", func(when bdd.When) {
+		when("p.Find(), p.FindAll() and p.Iter() are called", func(it bdd.It) {
+			_, errFind := NewTypedHandle("products", &mislabeled{}).Safely().SearchFor(M{"_id": fixture(1).ID()}).Find()
+			proda, errFindAll := NewTypedHandle("products", &mislabeled{}).Safely().FindAll()
+
+			iter := NewTypedHandle("products", &mislabeled{}).Safely().Iter()
+			_, ok := iter.Next()
+			errIter := iter.Close()
+
+			it("should return ErrTypeMismatch", func(assert bdd.Assert) {
+				assert.Equal(ErrTypeMismatch, errFind)
+				assert.Equal(ErrTypeMismatch, errFindAll)
+				assert.Equal(ErrTypeMismatch, errIter)
+			})
+			it("should return no documents", func(assert bdd.Assert) {
+				assert.Nil(proda)
+				assert.False(ok)
+			})
+		})
+	})
+}
+
+// mislabeled it's a product whose New returns a *product, instead of
+// a *mislabeled.
+type mislabeled struct {
+	product `bson:",inline"`
+}
@@ -0,0 +1,506 @@
+package mongo
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+)
+
+// ErrConflictingPaths it's an error received when an update uses the
+// same path, or paths contained in one another, on more than one
+// operation.
+var ErrConflictingPaths = errors.New("conflicting update paths")
+
+// ErrUpdateNotDefined it's an error received when an update received
+// is nil or isn't of a known type.
+var ErrUpdateNotDefined = errors.New("update not defined")
+
+// Ops it's a builder of MongoDB update operators, used to modify
+// documents atomically without replacing them. Each path can be used
+// on a single operation, otherwise Map returns an error. The zero value
+// of Ops is empty and ready to use.
+//
+// Ops can be used like this:
+//
+//	ops := mongo.NewOps().Inc("views", 1).AddToSet("tags", "new")
+//	err := p.UpdateWith(id, ops)
+type Ops struct {
+	ops   M
+	paths []string
+	err   error
+}
+
+// NewOps creates an empty Ops builder.
+func NewOps() (o *Ops) {
+	o = &Ops{
+		ops: M{},
+	}
+	return
+}
+
+// Set sets the value of field on path.
+func (o *Ops) Set(path string, v interface{}) *Ops {
+	return o.add("$set", path, v)
+}
+
+// Unset removes the field on path.
+func (o *Ops) Unset(path string) *Ops {
+	return o.add("$unset", path, "")
+}
+
+// Inc increments the field on path by n, which can be negative.
+func (o *Ops) Inc(path string, n interface{}) *Ops {
+	return o.add("$inc", path, n)
+}
+
+// Push appends v to the array on path.
+func (o *Ops) Push(path string, v interface{}) *Ops {
+	return o.add("$push", path, v)
+}
+
+// PushEach appends each of the values vs to the array on path.
+func (o *Ops) PushEach(path string, vs ...interface{}) *Ops {
+	return o.add("$push", path, M{"$each": vs})
+}
+
+// Pull removes from the array on path all values matching v, which
+// can be a value or a condition.
+func (o *Ops) Pull(path string, v interface{}) *Ops {
+	return o.add("$pull", path, v)
+}
+
+// AddToSet appends v to the array on path, only if it isn't there.
+func (o *Ops) AddToSet(path string, v interface{}) *Ops {
+	return o.add("$addToSet", path, v)
+}
+
+// AddEachToSet appends each of the values vs to the array on path,
+// only if they aren't there.
+func (o *Ops) AddEachToSet(path string, vs ...interface{}) *Ops {
+	return o.add("$addToSet", path, M{"$each": vs})
+}
+
+// Min updates the field on path to v, only if v is less than the
+// current value.
+func (o *Ops) Min(path string, v interface{}) *Ops {
+	return o.add("$min", path, v)
+}
+
+// Max updates the field on path to v, only if v is greater than the
+// current value.
+func (o *Ops) Max(path string, v interface{}) *Ops {
+	return o.add("$max", path, v)
+}
+
+// Has checks if path is already used by some operation.
+func (o *Ops) Has(path string) (found bool) {
+	for i := 0; i < len(o.paths) && !found; i++ {
+		found = o.paths[i] == path
+	}
+	return
+}
+
+// Map returns the update operators as a M object, to be used on
+// mgo.Collection methods. Returns an error if any path conflicts.
+func (o *Ops) Map() (out M, err error) {
+	if err = o.err; err == nil {
+		out = M{}
+		for op, fields := range o.ops {
+			out[op] = fields
+		}
+	}
+	return
+}
+
+// add stores the operation op on path with value v, verifying if path
+// doesn't conflict with other paths used.
+func (o *Ops) add(op, path string, v interface{}) *Ops {
+	for i := 0; i < len(o.paths) && o.err == nil; i++ {
+		if conflictingPaths(o.paths[i], path) {
+			o.err = fmt.Errorf("%w: %q and %q", ErrConflictingPaths, o.paths[i], path)
+		}
+	}
+
+	if o.err == nil {
+		if o.ops == nil {
+			o.ops = M{}
+		}
+
+		fields, ok := o.ops[op].(M)
+		if !ok {
+			fields = M{}
+			o.ops[op] = fields
+		}
+
+		fields[path] = v
+		o.paths = append(o.paths, path)
+	}
+
+	return o
+}
+
+// conflictingPaths checks if paths a and b are equal, or if one of
+// them contains the other.
+func conflictingPaths(a, b string) (conflict bool) {
+	conflict = a == b || strings.HasPrefix(a, b+".") || strings.HasPrefix(b, a+".")
+	return
+}
+
+// UpdateWith updates a document on collection connected to Handle,
+// matching id received, applying the update operators on ops. It also
+// sets updated_on, unless ops already sets it.
+func (h *Handle) UpdateWith(id ObjectId, ops *Ops) (err error) {
+	defer h.ifSafelyClose()
+	defer wrapErr(&err)
+
+	if err = h.InternalErr; err == nil {
+		if id == "" {
+			err = ErrIDNotDefined
+		} else {
+			var update M
+			if update, err = stampedOps(ops); err == nil {
+				idSelector := M{
+					"_id": id,
+				}
+
+				err = h.collection.Update(idSelector, update)
+			}
+		}
+	}
+
+	return
+}
+
+// UpdateAllWith updates all documents on collection connected to
+// Handle, matching the document data, applying the update operators
+// on ops. It also sets updated_on, unless ops already sets it.
+func (h *Handle) UpdateAllWith(ops *Ops) (info *mgo.ChangeInfo, err error) {
+	info, err = h.UpdateAll(ops)
+	return
+}
+
+// UpdateAll updates all documents on collection connected to Handle,
+// matching the document data. The update can be an *Ops, a M with
+// update operators, or a M with fields to be set on each document,
+// while a map[string]interface{} is used like a M. It also sets
+// updated_on on all documents updated.
+func (h *Handle) UpdateAll(update interface{}) (info *mgo.ChangeInfo, err error) {
+	defer h.ifSafelyClose()
+	defer wrapErr(&err)
+
+	if err = h.InternalErr; err == nil {
+		var mapped M
+		if mapped, err = h.mapped(); err == nil {
+			var operators M
+			if operators, err = updateOperators(update); err == nil {
+				info, err = h.collection.UpdateAll(mapped, operators)
+			}
+		}
+	}
+
+	return
+}
+
+// updateOperators translates the update received to update operators,
+// setting updated_on with actual time. The update can be an *Ops, a M
+// or map[string]interface{} with update operators, or with fields to
+// be set.
+func updateOperators(update interface{}) (out M, err error) {
+	switch u := update.(type) {
+	case *Ops:
+		out, err = stampedOps(u)
+	case map[string]interface{}:
+		out, err = updateOperators(toM(u))
+	case M:
+		if isOperators(u) {
+			out = stampOperators(u)
+		} else {
+			set := M{}
+			for k, v := range u {
+				set[k] = v
+			}
+			delete(set, "_id")
+
+			out = stampOperators(M{
+				"$set": set,
+			})
+		}
+	default:
+		err = ErrUpdateNotDefined
+	}
+
+	return
+}
+
+// stampedOps returns the update operators on ops, setting updated_on
+// with actual time if ops doesn't set it.
+func stampedOps(ops *Ops) (update M, err error) {
+	if ops == nil {
+		ops = NewOps()
+	}
+
+	if update, err = ops.Map(); err == nil {
+		update = stampOperators(update)
+	}
+
+	return
+}
+
+// stampOperators returns a copy of update operators received, setting
+// updated_on with actual time if no operator uses it.
+func stampOperators(update M) (out M) {
+	out = M{}
+	stamp := true
+	for op, v := range update {
+		if fields, ok := v.(M); ok {
+			if _, found := fields["updated_on"]; found {
+				stamp = false
+			}
+
+			copied := M{}
+			for k, fv := range fields {
+				copied[k] = fv
+			}
+			v = copied
+		}
+		out[op] = v
+	}
+
+	if stamp {
+		set, ok := out["$set"].(M)
+		if !ok {
+			set = M{}
+			out["$set"] = set
+		}
+		set["updated_on"] = NowInMilli()
+	}
+
+	return
+}
+
+// toM converts the map m to a M, converting also the maps on its
+// values, holding the fields of update operators.
+func toM(m map[string]interface{}) (out M) {
+	if m != nil {
+		out = M{}
+		for k, v := range m {
+			if fields, ok := v.(map[string]interface{}); ok {
+				v = M(fields)
+			}
+			out[k] = v
+		}
+	}
+	return
+}
+
+// isOperators checks if m it's made of update operators, instead of
+// fields of a document.
+func isOperators(m M) (operators bool) {
+	for k := range m {
+		if strings.HasPrefix(k, "$") {
+			operators = true
+			break
+		}
+	}
+	return
+}
+
+// updateDocument translates the update received to a document to be
+// used on mgo update methods, setting updated_on with actual time.
+// The update can be an *Ops, a M with update operators, or a M or
+// Documenter with fields replacing the whole document. A
+// map[string]interface{} is used like a M. Documents loaded
+// with a projection are refused with ErrPartialDocument, and are
+// validated before being used. Replacements without fields are refused
+// with ErrUpdateNotDefined, instead of wiping the document.
+func updateDocument(update interface{}) (out M, err error) {
+	switch u := update.(type) {
+	case *Ops:
+		out, err = stampedOps(u)
+	case map[string]interface{}:
+		out, err = updateDocument(toM(u))
+	case M:
+		if isOperators(u) {
+			out = stampOperators(u)
+		} else {
+			out = M{}
+			for k, v := range u {
+				out[k] = v
+			}
+			delete(out, "_id")
+
+			if len(out) == 0 {
+				out, err = nil, ErrUpdateNotDefined
+			} else {
+				out["updated_on"] = NowInMilli()
+			}
+		}
+	case Documenter:
+		if reflect.ValueOf(u).IsNil() {
+			err = ErrUpdateNotDefined
+		} else if IsPartial(u) {
+			err = ErrPartialDocument
+		} else {
+			bind(u)
+			if err = validationErr(u.Validate()); err == nil {
+				u.CalculateUpdatedOn()
+				if out, err = u.Map(); err == nil {
+					delete(out, "_id")
+					out["updated_on"] = u.UpdatedOn()
+				}
+			}
+		}
+	default:
+		err = ErrUpdateNotDefined
+	}
+
+	return
+}
+
+// Upsert inserts or updates atomically a document on collection
+// connected to Handle, using document data. The document to update is
+// matched by the search map, or by the document ID when the search map
+// it's empty, generating an ID if not defined.
+//
+// The created_on attribute is only set when a new document is
+// inserted, while updated_on is set on every write. The document on
+// Handle is refreshed with the values stored. Returns if a new
+// document was created, and its ID.
+func (h *Handle) Upsert() (created bool, id ObjectId, err error) {
+	defer h.ifSafelyClose()
+	defer wrapErr(&err)
+
+	if err = h.InternalErr; err == nil {
+		selector := h.SearchMap()
+		if h.IsSearchEmpty() {
+			if h.Document().ID() == "" {
+				h.Document().GenerateID()
+			}
+
+			selector = M{
+				"_id": h.Document().ID(),
+			}
+		}
+
+		var update M
+		if update, err = upsertDocument(selector, h.Document()); err == nil {
+			change := mgo.Change{
+				Update:    update,
+				Upsert:    true,
+				ReturnNew: true,
+			}
+
+			var result bson.Raw
+			var info *mgo.ChangeInfo
+			if info, err = h.collection.Find(selector).Apply(change, &result); err == nil {
+				created = info.UpsertedId != nil
+				if err = decode(result, h.Document()); err == nil {
+					id = h.Document().ID()
+				}
+			}
+		}
+	}
+
+	return
+}
+
+// upsertDocument translates the document d to an update to be used on
+// an upsert matching selector, setting created_on only on insertion
+// and updated_on always. The ID of d is also set on insertion, when
+// selector doesn't define one.
+func upsertDocument(selector M, d Documenter) (update M, err error) {
+	bind(d)
+	d.CalculateCreatedOn()
+	d.CalculateUpdatedOn()
+
+	var mapped M
+	if mapped, err = d.Map(); err == nil {
+		delete(mapped, "_id")
+		delete(mapped, "created_on")
+		mapped["updated_on"] = d.UpdatedOn()
+
+		onInsert := M{
+			"created_on": d.CreatedOn(),
+		}
+		if _, found := selector["_id"]; !found && d.ID() != "" {
+			onInsert["_id"] = d.ID()
+		}
+
+		update = M{
+			"$set":         mapped,
+			"$setOnInsert": onInsert,
+		}
+	}
+
+	return
+}
+
+// ModifyOptions enumerates the options of a FindAndModify operation.
+// Update can be an *Ops, a M with update operators, or a M or
+// Documenter replacing the whole document. A Documenter upserted sets
+// its fields like Upsert does, setting created_on only on insertion.
+// ReturnNew defines if the document returned it's the modified one,
+// instead of the original.
+type ModifyOptions struct {
+	Update    interface{}
+	Sort      []string
+	Upsert    bool
+	ReturnNew bool
+}
+
+// FindAndModify updates atomically a document on collection connected
+// to Handle, matching the document data, and returns it. When more
+// than one document matches, the Sort option selects which one to
+// modify. It also sets updated_on on the document modified.
+func (h *Handle) FindAndModify(opts ModifyOptions) (out Documenter, info *mgo.ChangeInfo, err error) {
+	defer h.ifSafelyClose()
+	defer wrapErr(&err)
+
+	if err = h.InternalErr; err == nil {
+		var mapped M
+		if mapped, err = h.mapped(); err == nil {
+			var update M
+			if update, err = modifyDocument(mapped, opts); err == nil {
+				err = h.checkSort(opts.Sort)
+			}
+
+			if err == nil {
+				qry := h.collection.Find(mapped)
+				if opts.Sort != nil {
+					qry = qry.Sort(opts.Sort...)
+				}
+
+				change := mgo.Change{
+					Update:    update,
+					Upsert:    opts.Upsert,
+					ReturnNew: opts.ReturnNew,
+				}
+
+				var result bson.Raw
+				if info, err = qry.Apply(change, &result); err == nil {
+					out = h.Document().New()
+					err = decode(result, out)
+				}
+			}
+		}
+	}
+
+	return
+}
+
+// modifyDocument translates the update of opts to be used on a
+// FindAndModify matching selector, like updateDocument does. When
+// upserting a Documenter, it's translated like Upsert does, so
+// created_on is set on insertion.
+func modifyDocument(selector M, opts ModifyOptions) (update M, err error) {
+	if update, err = updateDocument(opts.Update); err == nil && opts.Upsert {
+		if d, ok := opts.Update.(Documenter); ok {
+			update, err = upsertDocument(selector, d)
+		}
+	}
+	return
+}
@@ -14,6 +14,12 @@ var (
 	// newID it's stores imported generation of new ids for documents
 	// for mocking purposes.
 	newID = bson.NewObjectId
+	// documentOptions are the options used to translate documents,
+	// omitting empty fields and using the custom types registered.
+	documentOptions = bsonutils.Options{
+		OmitEmpty: true,
+		Registry:  registry,
+	}
 )
 
 // M is a convenient alias for a map[string]interface{} map, useful for
@@ -55,18 +61,15 @@ func NewID() (id bson.ObjectId) {
 
 // InitDocumenter translates a M received, to the Documenter
 // structure received as a pointer. It fills the structure fields with
-// the values of each key in the M received.
+// the values of each key in the M received. The M is serialized once
+// to be decoded, so operations of Handle decode the documents read
+// straight from BSON instead, without using it.
 func InitDocumenter(in M, out *Documenter) (err error) {
 	var marshalled []byte
-
-	bsonutils.SetOmitEmptyAsDefault(true)
-
-	if marshalled, err = bsonutils.Marshal(in); err == nil {
-		err = bsonutils.Unmarshal(marshalled, *out)
+	if marshalled, err = bsonutils.MarshalWith(documentOptions, in); err == nil {
+		err = bsonutils.UnmarshalWith(documentOptions, marshalled, *out)
 	}
 
-	bsonutils.SetOmitEmptyAsDefault(false)
-
 	return
 }
 
@@ -74,18 +77,6 @@ func InitDocumenter(in M, out *Documenter) (err error) {
 // it has, to a M object, more easily read by mgo.Collection
 // methods.
 func MapDocumenter(in Documenter) (out M, err error) {
-	var buf []byte
-	var target interface{}
-
-	bsonutils.SetOmitEmptyAsDefault(true)
-
-	if buf, err = bsonutils.Marshal(in); err == nil {
-		if err = bsonutils.Unmarshal(buf, &target); err == nil {
-			out = target.(M)
-		}
-	}
-
-	bsonutils.SetOmitEmptyAsDefault(false)
-
+	out, err = bsonutils.ToMapWith(documentOptions, in)
 	return
 }